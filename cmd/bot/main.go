@@ -0,0 +1,264 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ChengL-cisco/onlineGuanDan/pkg/bot"
+	"github.com/ChengL-cisco/onlineGuanDan/pkg/models"
+	"github.com/gorilla/websocket"
+)
+
+// maxRetries is the number of times the bot sends a tribute or a return again after the server refused it
+const maxRetries = 3
+
+var (
+	serverAddr = flag.String("server", "localhost:8080", "WebSocket server address")
+	name       = flag.String("name", "Bot", "Player name")
+	seat       = flag.Int("seat", -1, "Seat to take, -1 for the first available seat")
+	token      = flag.String("token", "", "Seat token to take the seat back after a disconnect")
+	search     = flag.Duration("search", 0, "Time to search each play for, 0 to play by heuristics only unless -iterations is set")
+	iterations = flag.Int("iterations", 0, "Deals to search each play over, 0 for no limit")
+	seed       = flag.Int64("seed", 0, "Seed of the deals searched, 0 for a seed from the clock")
+	index      = 0
+	profile    = models.DefaultRuleProfile()
+	player     bot.BotAPI
+	// pending is what the bot asked the table state for: "tribute", "return", "play", or "fallback" after a refused play
+	pending string
+	// sent is "tribute" or "return" while the server may still refuse the card the bot sent, and retries
+	// the number of times it was sent again
+	sent    string
+	retries int
+)
+
+// newPlayer returns the bot playing with the given house rules, searching its plays if a budget is set
+func newPlayer(profile models.RuleProfile) bot.BotAPI {
+	if *search <= 0 && *iterations <= 0 {
+		return bot.NewBot(profile)
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	return bot.NewMonteCarlo(profile, bot.Budget{Iterations: *iterations, Time: *search}, *seed)
+}
+
+// observe passes what the server reports of the table on to a bot that remembers it
+func observe(msg *models.ServerMessage) {
+	if observer, ok := player.(bot.Observer); ok {
+		if event, ok := bot.EventOf(msg, index); ok {
+			observer.Observe(event)
+		}
+	}
+}
+
+// ruleOf returns the rules of the table at the trump rank of state
+func ruleOf(state *models.TableState) *models.Rule {
+	info := &models.Info{}
+	if trump, err := models.ParseRank(state.TrumpRank); err == nil {
+		info.SetTrumpRank(trump)
+	}
+	rule := &models.Rule{}
+	rule.SetInfo(info)
+	rule.SetProfile(profile)
+	return rule
+}
+
+// lowest returns the weakest card of hand
+func lowest(rule *models.Rule, hand []models.Card) models.Card {
+	low := hand[0]
+	for _, card := range hand[1:] {
+		if rule.IsRankGreater(low.Rank, card.Rank) {
+			low = card
+		}
+	}
+	return low
+}
+
+// ask requests the table state to decide what to do for the pending action
+func ask(conn *websocket.Conn, action string) {
+	pending = action
+	send(conn, models.BuildClientMessage(index, "state", ""))
+}
+
+// send writes a message to the server
+func send(conn *websocket.Conn, message []byte) {
+	if err := conn.WriteMessage(websocket.TextMessage, message); err != nil {
+		log.Printf("Error sending message: %v", err)
+	}
+}
+
+// join takes the chosen seat, or the first available seat if the bot did not choose one or it is taken
+func join(conn *websocket.Conn, slotsData string) bool {
+	slots, _, err := models.ParseAvailableSlotsServerMessage(slotsData)
+	if err != nil || len(slots) == 0 {
+		log.Printf("No seat available: %s", slotsData)
+		return false
+	}
+	index = slots[0]
+	for _, slot := range slots {
+		if slot == *seat {
+			index = slot
+		}
+	}
+	log.Printf("Taking seat %d", index)
+	send(conn, models.BuildClientJoinMessage(index, *name, *token))
+	return true
+}
+
+// act answers the pending action from the table state
+func act(conn *websocket.Conn, state *models.TableState) {
+	action := pending
+	pending = ""
+	deck, err := models.NewDeckFromString(state.Hand)
+	if err != nil || deck.IsEmpty() {
+		return
+	}
+	hand := deck.GetCards()
+	switch action {
+	case "tribute":
+		sent = action
+		send(conn, models.BuildClientMessage(index, "tribute", player.Tribute(state, hand).CardString()))
+	case "return":
+		card := player.Return(state, hand)
+		if retries > 0 {
+			// the bot's choice was refused, give back the lowest card
+			card = lowest(ruleOf(state), hand)
+		}
+		sent = action
+		send(conn, models.BuildClientMessage(index, "return", card.CardString()))
+	case "play":
+		if state.Phase != models.PhasePlaying || state.CurrentIndex != index {
+			return
+		}
+		move := player.Play(state, hand)
+		if move.IsPass() {
+			send(conn, models.BuildClientMessage(index, "pass", ""))
+			return
+		}
+		log.Printf("Playing %s", models.CardsString(move.Cards))
+		data := models.ConstructClientPlayMessage(move.Cards, len(hand)-len(move.Cards), move.Equivalent, move.Declared)
+		send(conn, models.BuildClientPlayMessage(index, data, false))
+	case "fallback":
+		// the play was refused, pass or lead the lowest card of the hand
+		if state.LastPlayedCards != "" && state.LastPlayedIndex != index {
+			send(conn, models.BuildClientMessage(index, "pass", ""))
+			return
+		}
+		data := models.ConstructClientPlayMessage([]models.Card{lowest(ruleOf(state), hand)}, len(hand)-1, nil, nil)
+		send(conn, models.BuildClientPlayMessage(index, data, false))
+	}
+}
+
+func main() {
+	flag.Parse()
+	player = newPlayer(models.DefaultRuleProfile())
+
+	u := url.URL{Scheme: "ws", Host: *serverAddr, Path: "/ws"}
+	log.Printf("Connecting to %s", u.String())
+	conn, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+	if err != nil {
+		log.Fatal("dial:", err)
+	}
+	defer conn.Close()
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			log.Println("read:", err)
+			return
+		}
+		msg, err := models.ParseServerMessage(message)
+		if err != nil {
+			log.Printf("Failed to parse message: %v", err)
+			continue
+		}
+		observe(msg)
+		switch msg.Action {
+		case "availableSlots":
+			if !join(conn, msg.Data) {
+				return
+			}
+		case "joinConfirm":
+			seatToken, team, _ := strings.Cut(msg.Data, ";")
+			*token = seatToken
+			log.Printf("Joined seat %d in team %s", index, team)
+		case "rules":
+			rules, err := models.ParseRulesServerMessage(msg.Data)
+			if err != nil {
+				log.Printf("Failed to parse rules message: %v", err)
+				continue
+			}
+			log.Printf("House rules %s", rules)
+			profile = *rules
+			player = newPlayer(profile)
+		case "allJoined":
+			send(conn, models.BuildClientMessage(index, "ready", *name))
+		case "seatsSwapped":
+			from, _, fields, err := models.ParsePlayerServerMessage(msg.Data)
+			if err != nil || len(fields) != 2 {
+				log.Printf("Failed to parse seatsSwapped message: %s", msg.Data)
+				continue
+			}
+			to, _ := strconv.Atoi(fields[0])
+			if index == from {
+				index = to
+			} else if index == to {
+				index = from
+			}
+		case "arrange":
+			send(conn, models.BuildClientMessage(index, "start", *name))
+		case "tributeDue":
+			if from, _, _, _, err := models.ParseTributeServerMessage(msg.Data); err == nil && from == index {
+				ask(conn, "tribute")
+			}
+		case "tributePaid", "cardReturned":
+			from, to, _, _, err := models.ParseTributeServerMessage(msg.Data)
+			if err != nil {
+				continue
+			}
+			if from == index {
+				sent, retries = "", 0
+			}
+			if to == index && msg.Action == "tributePaid" {
+				ask(conn, "return")
+			}
+		case "play":
+			if playerIndex, _, _, err := models.ParsePlayerServerMessage(msg.Data); err == nil && playerIndex == index {
+				ask(conn, "play")
+			}
+		case "invalidPlay":
+			log.Printf("Play refused: %s", msg.Data)
+			perr, err := models.ParseErrorServerMessage(msg.Data)
+			if err == nil && (perr.Code == models.CodeNotYourTurn || perr.Code == models.CodeWrongPhase) {
+				continue
+			}
+			ask(conn, "fallback")
+		case "state":
+			state, err := models.ParseStateServerMessage(msg.Data)
+			if err != nil {
+				log.Printf("Failed to parse state message: %v", err)
+				continue
+			}
+			act(conn, state)
+		case "error":
+			log.Printf("Server error: %s", msg.Data)
+			if sent == "" {
+				continue
+			}
+			if retries >= maxRetries {
+				log.Printf("Giving up on the %s after %d refusals", sent, retries)
+				sent, retries = "", 0
+				continue
+			}
+			// the tribute or the return was refused, decide again from the current table
+			retries++
+			ask(conn, sent)
+		case "roundOver", "matchOver":
+			log.Printf("%s %s", msg.Action, msg.Data)
+		}
+	}
+}
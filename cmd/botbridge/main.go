@@ -0,0 +1,456 @@
+// Botbridge takes a seat at the table for a bot written in any language, run as a child process
+// given after the flags, for example: botbridge -name py -- python3 bot.py
+//
+// The bridge writes one JSON object per line to the standard input of the child:
+//
+//	{"type":"rules","rules":{...}}                                      the house rules of the table
+//	{"type":"message","action":"lastPlay","data":"..."}                 any other server message, as the server sent it
+//	{"type":"request","id":7,"request":"play","timeLimit":5000,"state":{...}}
+//
+// A request asks for a "play", a "tribute" or a "return" card with the table state as the player sees it,
+// and must be answered within timeLimit milliseconds with one JSON object on a line of the standard output:
+//
+//	{"id":7,"action":"play","cards":"8-S 8-H","equivalent":"8-S 8-D"}  equivalent is only needed for wild cards
+//	{"id":7,"action":"pass"}
+//	{"id":7,"action":"tribute","card":"A-S"}
+//	{"id":7,"action":"return","card":"3-D"}
+//
+// Cards are written as in the server messages. When the child does not answer in time, or its answer
+// cannot be read or is refused by the server, the bridge passes or plays the lowest card of the hand,
+// and pays its highest card as tribute and returns its lowest card. The standard error of the child is the bridge's.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ChengL-cisco/onlineGuanDan/pkg/bot"
+	"github.com/ChengL-cisco/onlineGuanDan/pkg/models"
+	"github.com/gorilla/websocket"
+)
+
+var (
+	serverAddr = flag.String("server", "localhost:8080", "WebSocket server address")
+	name       = flag.String("name", "Bridge", "Player name")
+	seat       = flag.Int("seat", -1, "Seat to take, -1 for the first available seat")
+	token      = flag.String("token", "", "Seat token to take the seat back after a disconnect")
+	timeLimit  = flag.Duration("timeLimit", 5*time.Second, "Time the child has to answer each request")
+	index      = 0
+	profile    = models.DefaultRuleProfile()
+	child      *process
+	// pending is what the bridge asked the table state for: "tribute", "return" or "play"
+	pending string
+	// fallback is true when the child's answer to the pending request was refused and the bridge answers itself
+	fallback bool
+	// pendingCard is "tribute" or "return" while the server may still refuse the card the child chose
+	pendingCard string
+)
+
+// request is a line written to the child
+type request struct {
+	Type      string              `json:"type"`
+	ID        int                 `json:"id,omitempty"`
+	Request   string              `json:"request,omitempty"`
+	TimeLimit int64               `json:"timeLimit,omitempty"`
+	State     *models.TableState  `json:"state,omitempty"`
+	Rules     *models.RuleProfile `json:"rules,omitempty"`
+	Action    string              `json:"action,omitempty"`
+	Data      string              `json:"data,omitempty"`
+}
+
+// answer is a line read from the child
+type answer struct {
+	ID         int    `json:"id"`
+	Action     string `json:"action"`
+	Cards      string `json:"cards"`
+	Equivalent string `json:"equivalent"`
+	Card       string `json:"card"`
+}
+
+// process is the child bot with its standard input and the lines of its standard output
+type process struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	lines  chan string
+	nextID int
+}
+
+// start runs the child bot from its command line
+func start(args []string) (*process, error) {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	p := &process{cmd: cmd, stdin: stdin, lines: make(chan string, 16)}
+	go func() {
+		defer close(p.lines)
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			p.lines <- scanner.Text()
+		}
+	}()
+	return p, nil
+}
+
+// send writes req to the child as a line of JSON
+func (p *process) send(req request) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	_, err = p.stdin.Write(append(data, '\n'))
+	return err
+}
+
+// ask sends the child a request for what with state and waits for its answer until the time limit,
+// answers to earlier requests that came too late being dropped
+func (p *process) ask(what string, state *models.TableState) (*answer, error) {
+	for drained := false; !drained; {
+		select {
+		case line, ok := <-p.lines:
+			if !ok {
+				return nil, errors.New("the bot exited")
+			}
+			log.Printf("Dropping late answer: %s", line)
+		default:
+			drained = true
+		}
+	}
+	p.nextID++
+	id := p.nextID
+	if err := p.send(request{Type: "request", ID: id, Request: what, TimeLimit: timeLimit.Milliseconds(), State: state}); err != nil {
+		return nil, err
+	}
+	timer := time.NewTimer(*timeLimit)
+	defer timer.Stop()
+	for {
+		select {
+		case line, ok := <-p.lines:
+			if !ok {
+				return nil, errors.New("the bot exited")
+			}
+			var a answer
+			if err := json.Unmarshal([]byte(line), &a); err != nil {
+				return nil, fmt.Errorf("cannot read answer %q: %v", line, err)
+			}
+			if a.ID != 0 && a.ID != id {
+				log.Printf("Dropping answer to request %d", a.ID)
+				continue
+			}
+			if a.Action != what && !(what == "play" && a.Action == "pass") {
+				return nil, fmt.Errorf("answer %q to a %s request", a.Action, what)
+			}
+			return &a, nil
+		case <-timer.C:
+			return nil, fmt.Errorf("no answer to the %s request within %v", what, *timeLimit)
+		}
+	}
+}
+
+// ask requests the table state to answer the pending action, by the bridge itself if fallBack is true
+func ask(conn *websocket.Conn, action string, fallBack bool) {
+	pending, fallback = action, fallBack
+	send(conn, models.BuildClientMessage(index, "state", ""))
+}
+
+// send writes a message to the server
+func send(conn *websocket.Conn, message []byte) {
+	if err := conn.WriteMessage(websocket.TextMessage, message); err != nil {
+		log.Printf("Error sending message: %v", err)
+	}
+}
+
+// join takes the chosen seat, or the first available seat if none was chosen or it is taken
+func join(conn *websocket.Conn, slotsData string) bool {
+	slots, _, err := models.ParseAvailableSlotsServerMessage(slotsData)
+	if err != nil || len(slots) == 0 {
+		log.Printf("No seat available: %s", slotsData)
+		return false
+	}
+	index = slots[0]
+	for _, slot := range slots {
+		if slot == *seat {
+			index = slot
+		}
+	}
+	log.Printf("Taking seat %d", index)
+	send(conn, models.BuildClientJoinMessage(index, *name, *token))
+	return true
+}
+
+// ruleOf returns the rules of the round of state
+func ruleOf(state *models.TableState) *models.Rule {
+	info := &models.Info{}
+	if trump, err := models.ParseRank(state.TrumpRank); err == nil {
+		info.SetTrumpRank(trump)
+	}
+	rule := &models.Rule{}
+	rule.SetInfo(info)
+	rule.SetProfile(profile)
+	return rule
+}
+
+// following returns true if the player of state has a play to beat rather than a trick to lead
+func following(state *models.TableState) bool {
+	return state.LastPlayedCards != "" && state.LastPlayedIndex != state.Index
+}
+
+// act answers the pending action from the table state, with the child's answer unless it falls back
+func act(conn *websocket.Conn, state *models.TableState) {
+	action, fallBack := pending, fallback
+	pending, fallback = "", false
+	deck, err := models.NewDeckFromString(state.Hand)
+	if err != nil || deck.IsEmpty() || action == "" {
+		return
+	}
+	hand := deck.GetCards()
+	if action == "play" && (state.Phase != models.PhasePlaying || state.CurrentIndex != index) {
+		return
+	}
+	if !fallBack {
+		a, err := child.ask(action, state)
+		if err == nil {
+			err = answerWith(conn, state, action, a)
+		}
+		if err == nil {
+			return
+		}
+		log.Printf("Falling back: %v", err)
+	}
+
+	// answer for the child: pass or play the lowest card, pay the highest card and return the lowest
+	switch action {
+	case "tribute":
+		send(conn, models.BuildClientMessage(index, "tribute", bot.NewBot(profile).Tribute(state, hand).CardString()))
+	case "return":
+		send(conn, models.BuildClientMessage(index, "return", lowest(ruleOf(state), hand).CardString()))
+	case "play":
+		if following(state) {
+			send(conn, models.BuildClientMessage(index, "pass", ""))
+			return
+		}
+		card := lowest(ruleOf(state), hand)
+		send(conn, models.BuildClientPlayMessage(index, models.ConstructClientPlayMessage([]models.Card{card}, len(hand)-1, nil, nil), false))
+	}
+}
+
+// answerWith sends the server the child's answer to action, or returns why it cannot be sent
+func answerWith(conn *websocket.Conn, state *models.TableState, action string, a *answer) error {
+	switch a.Action {
+	case "pass":
+		send(conn, models.BuildClientMessage(index, "pass", ""))
+	case "tribute", "return":
+		card, err := models.ParseCard(a.Card)
+		if err != nil {
+			return fmt.Errorf("cannot read card %q: %v", a.Card, err)
+		}
+		pendingCard = action
+		send(conn, models.BuildClientMessage(index, action, card.CardString()))
+	case "play":
+		cards, err := models.NewDeckFromString(a.Cards)
+		if err != nil || cards.IsEmpty() {
+			return fmt.Errorf("cannot read cards %q", a.Cards)
+		}
+		var equivalent []models.Card
+		if a.Equivalent != "" {
+			deck, err := models.NewDeckFromString(a.Equivalent)
+			if err != nil {
+				return fmt.Errorf("cannot read equivalent %q: %v", a.Equivalent, err)
+			}
+			equivalent = deck.GetCards()
+		} else {
+			equivalent = reading(ruleOf(state), state, cards.GetCards())
+		}
+		hand, err := models.NewDeckFromString(state.Hand)
+		if err != nil {
+			return fmt.Errorf("cannot read hand %q: %v", state.Hand, err)
+		}
+		left := hand.Count() - cards.Count()
+		send(conn, models.BuildClientPlayMessage(index, models.ConstructClientPlayMessage(cards.GetCards(), left, equivalent, nil), false))
+	}
+	return nil
+}
+
+// reading returns what the wild cards among cards stand for in the strongest reading that can be played,
+// or nil if there is no wild card among cards
+func reading(rule *models.Rule, state *models.TableState, cards []models.Card) []models.Card {
+	readings := rule.Readings(cards)
+	if len(readings) == 0 || len(readings[0].Equivalent) == 0 {
+		return nil
+	}
+	wild := false
+	for _, card := range cards {
+		wild = wild || rule.IsWildCard(card)
+	}
+	if !wild {
+		return nil
+	}
+	if following(state) {
+		if last, err := models.NewDeckFromString(state.LastPlayedCards); err == nil {
+			if top, err := rule.Classify(last.GetCards()); err == nil {
+				for _, r := range readings {
+					if rule.Beats(top, r.Combination) {
+						return r.Equivalent
+					}
+				}
+			}
+		}
+	}
+	return readings[0].Equivalent
+}
+
+// lowest returns the weakest card of hand
+func lowest(rule *models.Rule, hand []models.Card) models.Card {
+	low := hand[0]
+	for _, card := range hand[1:] {
+		if rule.IsRankGreater(low.Rank, card.Rank) {
+			low = card
+		}
+	}
+	return low
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] -- command [args...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var err error
+	if child, err = start(flag.Args()); err != nil {
+		log.Fatalf("Cannot start the bot: %v", err)
+	}
+	defer child.cmd.Process.Kill()
+
+	u := url.URL{Scheme: "ws", Host: *serverAddr, Path: "/ws"}
+	log.Printf("Connecting to %s", u.String())
+	conn, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+	if err != nil {
+		log.Fatal("dial:", err)
+	}
+	defer conn.Close()
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			log.Println("read:", err)
+			return
+		}
+		msg, err := models.ParseServerMessage(message)
+		if err != nil {
+			log.Printf("Failed to parse message: %v", err)
+			continue
+		}
+		switch msg.Action {
+		case "rules":
+			if rules, err := models.ParseRulesServerMessage(msg.Data); err == nil {
+				profile = *rules
+				err = child.send(request{Type: "rules", Rules: rules})
+			}
+			if err != nil {
+				log.Printf("Failed to pass the rules on: %v", err)
+			}
+		case "state":
+		default:
+			if err := child.send(request{Type: "message", Action: msg.Action, Data: msg.Data}); err != nil {
+				log.Printf("The bot is gone: %v", err)
+				return
+			}
+		}
+
+		switch msg.Action {
+		case "availableSlots":
+			if !join(conn, msg.Data) {
+				return
+			}
+		case "joinConfirm":
+			seatToken, team, _ := strings.Cut(msg.Data, ";")
+			*token = seatToken
+			log.Printf("Joined seat %d in team %s", index, team)
+		case "allJoined":
+			send(conn, models.BuildClientMessage(index, "ready", *name))
+		case "seatsSwapped":
+			from, _, fields, err := models.ParsePlayerServerMessage(msg.Data)
+			if err != nil || len(fields) != 2 {
+				log.Printf("Failed to parse seatsSwapped message: %s", msg.Data)
+				continue
+			}
+			to, _ := strconv.Atoi(fields[0])
+			if index == from {
+				index = to
+			} else if index == to {
+				index = from
+			}
+		case "arrange":
+			send(conn, models.BuildClientMessage(index, "start", *name))
+		case "tributeDue":
+			if from, _, _, _, err := models.ParseTributeServerMessage(msg.Data); err == nil && from == index {
+				ask(conn, "tribute", false)
+			}
+		case "tributePaid", "cardReturned":
+			from, to, _, _, err := models.ParseTributeServerMessage(msg.Data)
+			if err != nil {
+				continue
+			}
+			if from == index {
+				pendingCard = ""
+			}
+			if to == index && msg.Action == "tributePaid" {
+				ask(conn, "return", false)
+			}
+		case "play":
+			if playerIndex, _, _, err := models.ParsePlayerServerMessage(msg.Data); err == nil && playerIndex == index {
+				ask(conn, "play", false)
+			}
+		case "invalidPlay":
+			log.Printf("Play refused: %s", msg.Data)
+			perr, err := models.ParseErrorServerMessage(msg.Data)
+			if err == nil && (perr.Code == models.CodeNotYourTurn || perr.Code == models.CodeWrongPhase) {
+				continue
+			}
+			ask(conn, "play", true)
+		case "error":
+			log.Printf("Server error: %s", msg.Data)
+			perr, err := models.ParseErrorServerMessage(msg.Data)
+			if err == nil && pendingCard != "" && (perr.Code == models.CodeIllegalTribute || perr.Code == models.CodeCardsNotInHand) {
+				ask(conn, pendingCard, true)
+				pendingCard = ""
+			}
+		case "state":
+			state, err := models.ParseStateServerMessage(msg.Data)
+			if err != nil {
+				log.Printf("Failed to parse state message: %v", err)
+				continue
+			}
+			act(conn, state)
+		case "roundOver", "matchOver":
+			log.Printf("%s %s", msg.Action, msg.Data)
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ChengL-cisco/onlineGuanDan/pkg/bot"
 	"github.com/ChengL-cisco/onlineGuanDan/pkg/models"
 	"github.com/gorilla/websocket"
 )
@@ -19,20 +20,34 @@ import (
 var (
 	serverAddr        = flag.String("server", "localhost:8080", "WebSocket server address")
 	name              = flag.String("name", "Player", "Player name")
+	token             = flag.String("token", "", "Seat token to take your seat back after a disconnect")
+	notationName      = flag.String("notation", string(models.NotationSymbol), "Notation cards are shown in: protocol, compact, symbol or chinese")
+	notation          models.Notation
+	sortName          = flag.String("sort", string(models.SortByStrength), "How the hand is sorted: strength, suit or combination")
+	sortStrategy      models.SortStrategy
+	assist            = flag.Bool("assist", false, "Show on your turn what can be inferred of the hands of the other players")
+	beliefs           *bot.Beliefs // what the round so far tells of the other hands, kept with -assist
 	reader            = bufio.NewReader(os.Stdin)
 	index             = 0
 	playerDeck        *models.Deck
 	playAttempt       []models.Card
 	equivalentAttempt []models.Card
 	trumpRank         models.Rank
+	profile           models.RuleProfile // house rules of the table, the default rules until the server sends them
 	finishedIndexes   []int
+	pendingCard       string                // "tribute" or "return" while the server waits for a card from us
+	inLobby           bool                  // true while everybody joined and we may still change seats before getting ready
+	readerDone        = make(chan struct{}) // closed when the connection can no longer be read
 )
 
 func organizeCards(conn *websocket.Conn) {
 	for {
-		fmt.Println("Type 'y' to indicate you are ready to start or select the card index or index range:")
+		fmt.Println("Type 'y' to indicate you are ready to start, 'strength', 'suit' or 'combination' to sort your cards, or select the card index or index range:")
 		var input string
 		fmt.Scan(&input)
+		if sortHand(input) {
+			continue
+		}
 		if input == "y" {
 			if err := conn.WriteMessage(websocket.TextMessage, models.BuildClientMessage(index, "start", *name)); err != nil {
 				log.Printf("Error sending start message: %v", err)
@@ -112,32 +127,38 @@ func disconnect(conn *websocket.Conn) error {
 		return fmt.Errorf("error sending close message: %w", err)
 	}
 
-	// Wait for the server to acknowledge the close message
-	conn.SetReadDeadline(time.Now().Add(5 * time.Second)) // Set a read deadline for the acknowledgement
-	for {
-		_, _, err = conn.ReadMessage()
-		if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
-			break // Server acknowledged the closure
-		}
-		if err != nil {
-			break // An error occurred
-		}
+	// Wait for the server to acknowledge the close message, which ends the reader
+	select {
+	case <-readerDone:
+	case <-time.After(5 * time.Second):
 	}
 
 	// Close the connection
 	return conn.Close()
 }
 
-// handleAllJoined handles the allJoined message from the server
+// handleAllJoined handles the allJoined message from the server.
+// Before getting ready the player can move to another seat with S<seat> or to another team with T<team>,
+// and is asked again once the server answered.
 func handleAllJoined(conn *websocket.Conn) error {
 	log.Printf("Everybody joined, ready to start")
-	fmt.Print("Type Y to indicate ready to start or N to quit the game ")
+	inLobby = true
+	fmt.Print("Type Y to indicate ready to start, N to quit the game, S<seat> to swap seats or T<team> to change teams ")
 	readyToStart, err := reader.ReadString('\n')
 	if err != nil {
 		return fmt.Errorf("error reading input: %w", err)
 	}
 	readyToStart = strings.ToUpper(strings.TrimSpace(readyToStart))
 
+	if len(readyToStart) > 1 {
+		if action, ok := map[byte]string{'S': "swap", 'T': "team"}[readyToStart[0]]; ok {
+			if err := conn.WriteMessage(websocket.TextMessage, models.BuildClientMessage(index, action, readyToStart[1:])); err != nil {
+				return fmt.Errorf("error sending %s message: %w", action, err)
+			}
+			return nil
+		}
+	}
+
 	if readyToStart == "N" {
 		log.Println("User chose to quit the game")
 		if err := disconnect(conn); err != nil {
@@ -146,6 +167,7 @@ func handleAllJoined(conn *websocket.Conn) error {
 		return fmt.Errorf("user chose to quit the game")
 	}
 
+	inLobby = false
 	if err := conn.WriteMessage(websocket.TextMessage, models.BuildClientMessage(index, "ready", *name)); err != nil {
 		return fmt.Errorf("error sending ready message: %w", err)
 	}
@@ -153,17 +175,53 @@ func handleAllJoined(conn *websocket.Conn) error {
 	return nil
 }
 
-func getCardsFromIndexes() []models.Card {
+// playChoice is what the player picked at the play prompt
+type playChoice int
+
+// Constants for play prompt choices
+const (
+	choicePlay  playChoice = iota // play the selected cards
+	choiceCheck                   // only ask the server to check the selected cards
+	choicePass                    // pass the turn
+	choiceState                   // show the table state
+	choiceLeave                   // leave the table
+)
+
+// getCardsFromIndexes prompts the player for the cards to play and returns them with the player's choice.
+// The cards are picked by their indexes or written in any card notation, as in "KKK22".
+// Prefixing the selection with '?' only checks it, 'p' passes, 's' shows the table state
+// and 'q' leaves the table
+func getCardsFromIndexes() ([]models.Card, playChoice) {
 	for {
 		fmt.Println(playerDeck.String())
 		fmt.Println("It's your turn to play!")
-		fmt.Println("pick the card indexes to play or type 'p' to pass:")
+		fmt.Println("pick the card indexes or write the cards to play (e.g. 'KKK22' or 'TsJs'), prefix them with '?' to only check the selection, type 'p' to pass, 's' to show the table, 'strength', 'suit' or 'combination' to sort your cards or 'q' to leave:")
 		var input string
 		fmt.Scan(&input)
-		fmt.Println(input)
-		fmt.Println(input == "p")
+		if sortHand(input) {
+			continue
+		}
 		if input == "p" {
-			return nil
+			return nil, choicePass
+		}
+		if input == "s" {
+			return nil, choiceState
+		}
+		if input == "q" {
+			return nil, choiceLeave
+		}
+		choice := choicePlay
+		if strings.HasPrefix(input, "?") {
+			choice = choiceCheck
+		}
+		input = strings.TrimPrefix(input, "?")
+		if strings.Trim(input, "0123456789,-") != "" {
+			cards, ok := cardsFromNotation(input)
+			if !ok {
+				fmt.Println("Invalid cards. Please write cards of your hand, e.g. 'KKK22' or 'TsJs'")
+				continue
+			}
+			return cards, choice
 		}
 		var start, end int
 		var sourceIndexes []int
@@ -214,19 +272,156 @@ func getCardsFromIndexes() []models.Card {
 				cards = append(cards, playerDeck.GetCards()[idx])
 			}
 		}
-		return cards
+		return cards, choice
 	}
 }
 
-// selectAndJoinSlot handles the slot selection and join process
+// promptPlay asks the player for a play and sends it to the server, or passes if no cards were picked
+func promptPlay(conn *websocket.Conn) {
+	cards, choice := getCardsFromIndexes()
+	switch choice {
+	case choicePass:
+		conn.WriteMessage(websocket.TextMessage, models.BuildClientMessage(index, "pass", ""))
+		return
+	case choiceState:
+		conn.WriteMessage(websocket.TextMessage, models.BuildClientMessage(index, "state", ""))
+		return
+	case choiceLeave:
+		conn.WriteMessage(websocket.TextMessage, models.BuildClientMessage(index, "leave", *name))
+		if err := disconnect(conn); err != nil {
+			log.Printf("Error disconnecting: %v", err)
+		}
+		return
+	}
+	playAttempt = cards
+	var declared *models.Combination
+	equivalentAttempt, declared = chooseReading(cards)
+	msg := models.ConstructClientPlayMessage(cards, playerDeck.Count()-len(cards), equivalentAttempt, declared)
+	conn.WriteMessage(websocket.TextMessage, models.BuildClientPlayMessage(index, msg, choice == choiceCheck))
+}
+
+// formatCards writes cards received in the protocol notation in the notation chosen by the player
+func formatCards(cardsStr string) string {
+	deck, err := models.NewDeckFromString(cardsStr)
+	if err != nil {
+		return cardsStr
+	}
+	return models.FormatCards(deck.GetCards(), notation)
+}
+
+// cardsFromNotation returns the cards of the hand written in input, as a compact string such as "KKK22"
+// or as a single card in any notation, and false if input names cards the hand does not hold
+func cardsFromNotation(input string) ([]models.Card, bool) {
+	cards, err := models.ParseHand(input)
+	if err != nil {
+		card, err := models.ParseCard(input)
+		if err != nil {
+			return nil, false
+		}
+		cards = []models.Card{card}
+	}
+	if len(cards) == 0 {
+		return nil, false
+	}
+	return playerDeck.FindN(cards)
+}
+
+// promptCard asks the player for a card to give away and sends it to the server with the given action,
+// "tribute" to pay tribute or "return" to give a card back
+func promptCard(conn *websocket.Conn, action string) {
+	pendingCard = action
+	for {
+		fmt.Println(playerDeck.String())
+		if action == "tribute" {
+			fmt.Println("You pay tribute, pick the index or write your highest card other than a wild card:")
+		} else {
+			fmt.Println("Pick the index or write the card to return, of rank 10 or lower if you have one:")
+		}
+		var input string
+		fmt.Scan(&input)
+		var card models.Card
+		if idx, err := strconv.Atoi(input); err == nil && idx >= 0 && idx < playerDeck.Count() {
+			card = playerDeck.GetCards()[idx]
+		} else if cards, ok := cardsFromNotation(input); ok && len(cards) == 1 {
+			card = cards[0]
+		} else {
+			fmt.Println("Invalid card. Please pick a card of your hand")
+			continue
+		}
+		conn.WriteMessage(websocket.TextMessage, models.BuildClientMessage(index, action, card.CardString()))
+		return
+	}
+}
+
+// printState prints a snapshot of the table
+func printState(state *models.TableState) {
+	fmt.Printf("Phase: %s, trump rank: %s\n", state.Phase, state.TrumpRank)
+	for t, team := range state.Teams {
+		fmt.Printf("Team %d %s: level %s, seats %v\n", t, team.Name, team.Level, team.Members)
+	}
+	for _, seat := range state.Seats {
+		status := ""
+		if seat.Index == state.CurrentIndex && state.Phase == models.PhasePlaying {
+			status += " (turn)"
+		}
+		for _, i := range state.PassedIndexes {
+			if i == seat.Index {
+				status += " (passed)"
+			}
+		}
+		for rank, i := range state.FinishedIndexes {
+			if i == seat.Index {
+				status += fmt.Sprintf(" (finished #%d)", rank+1)
+			}
+		}
+		fmt.Printf("Seat %d %s, team %d: %d cards%s\n", seat.Index, seat.Name, seat.Team, seat.CardsLeft, status)
+	}
+	if state.LastPlayedCards != "" {
+		fmt.Printf("Top play by player %d: %s\n", state.LastPlayedIndex, formatCards(state.LastPlayedCards))
+	}
+	fmt.Printf("Your hand: %s\n", formatCards(state.Hand))
+	if *assist && state.Phase == models.PhasePlaying {
+		printAssist(state)
+	}
+}
+
+// printAssist prints what the round so far tells of the hands of the other players
+func printAssist(state *models.TableState) {
+	deck, err := models.NewDeckFromString(state.Hand)
+	if err != nil {
+		return
+	}
+	hand := deck.GetCards()
+	trump, err := models.ParseRank(state.TrumpRank)
+	if err != nil {
+		return
+	}
+	fmt.Printf("Not seen yet: %d wild cards, %d big jokers\n", beliefs.Unseen(state, hand, models.NewCard(models.Heart, trump)),
+		beliefs.Unseen(state, hand, models.Card{Rank: models.BigJoker}))
+	for _, seat := range state.Seats {
+		if seat.Index == state.Index || seat.CardsLeft == 0 {
+			continue
+		}
+		fmt.Printf("Seat %d: %d cards, %.0f%% chance of holding a bomb\n", seat.Index, seat.CardsLeft, 100*beliefs.BombProbability(state, hand, seat.Index))
+	}
+}
+
+// selectAndJoinSlot handles the slot selection and join process,
+// the player picks a slot by number or the first free slot of a team with T<team>
 func selectAndJoinSlot(conn *websocket.Conn, slotsData string) error {
-	slots := strings.Fields(slotsData)
+	slots, teams, err := models.ParseAvailableSlotsServerMessage(slotsData)
+	if err != nil {
+		return fmt.Errorf("invalid available slots: %w", err)
+	}
 	if len(slots) == 0 {
 		return fmt.Errorf("no available slots")
 	}
 
-	fmt.Printf("Available slots: %s, pick one to join\n", slotsData)
-	fmt.Print("Enter slot number to join: ")
+	fmt.Println("Available slots, pick one to join:")
+	for i, slot := range slots {
+		fmt.Printf("  slot %d, team %d\n", slot, teams[i])
+	}
+	fmt.Print("Enter slot number to join, or T<team> to join a team: ")
 
 	// Read input with proper error handling
 	selectedSlot, err := reader.ReadString('\n')
@@ -242,6 +437,23 @@ func selectAndJoinSlot(conn *websocket.Conn, slotsData string) error {
 
 	log.Printf("Selected slot: %s", selectedSlot)
 
+	if teamStr, ok := strings.CutPrefix(strings.ToUpper(selectedSlot), "T"); ok {
+		team, err := strconv.Atoi(teamStr)
+		if err != nil {
+			return fmt.Errorf("invalid team number: %w", err)
+		}
+		selectedSlot = ""
+		for i, slot := range slots {
+			if teams[i] == team {
+				selectedSlot = strconv.Itoa(slot)
+				break
+			}
+		}
+		if selectedSlot == "" {
+			return fmt.Errorf("team %d has no available slot", team)
+		}
+	}
+
 	index, err = strconv.Atoi(selectedSlot)
 	if err != nil {
 		return fmt.Errorf("invalid slot number: %w", err)
@@ -250,88 +462,98 @@ func selectAndJoinSlot(conn *websocket.Conn, slotsData string) error {
 	log.Printf("Selected index: %d", index)
 
 	// Send the selected slot back to the server
-	if err := conn.WriteMessage(websocket.TextMessage, models.BuildClientMessage(index, "join", *name)); err != nil {
+	if err := conn.WriteMessage(websocket.TextMessage, models.BuildClientJoinMessage(index, *name, *token)); err != nil {
 		return fmt.Errorf("error sending join message: %w", err)
 	}
 
 	return nil
 }
 
-// handleWildCards checks for wild cards in the playAttempt and prompts the user for their equivalent
-// Returns the equivalent attempt with wild cards replaced, or an error if input is invalid
-func handleWildCards(playAttempt []models.Card, trumpRank models.Rank) []models.Card {
-	equivalent := make([]models.Card, len(playAttempt))
-	copy(equivalent, playAttempt)
-	replaced := false
-
-	for i, card := range playAttempt {
-		// Check if card is a heart or the current trump rank
-		if card.Suit == models.Heart && card.Rank == trumpRank {
-			replaced = false
-			for !replaced {
-				fmt.Printf("Wild card detected: %s\n", card.String())
-				fmt.Println("Enter the card this should be equivalent to (e.g., 'A-S' for Ace of Spades):")
-
-				reader := bufio.NewReader(os.Stdin)
-				input, err := reader.ReadString('\n')
-				if err != nil {
-					log.Printf("error reading input: %v", err)
-					continue
-				}
-				input = strings.TrimSpace(input)
-
-				// Parse the input card
-				if input == "Jr" || input == "BJr" {
-					log.Printf("cannot use jokers as equivalent cards")
-					continue
-				}
+// clientRule returns the rules of the table as far as the client knows them: the trump rank and the house rules
+func clientRule() *models.Rule {
+	info := &models.Info{}
+	info.SetTrumpRank(trumpRank)
+	rule := &models.Rule{}
+	rule.SetInfo(info)
+	rule.SetProfile(profile)
+	return rule
+}
 
-				parts := strings.Split(input, "-")
-				if len(parts) != 2 {
-					log.Printf("invalid card format, expected format like 'A-S' or '10-H'")
-					continue
-				}
+// sortHand sorts the hand with the sort strategy named by input and makes it the strategy used from then on.
+// It returns false if input names no sort strategy.
+func sortHand(input string) bool {
+	strategy, err := models.ParseSortStrategy(input)
+	if err != nil {
+		return false
+	}
+	sortStrategy = strategy
+	playerDeck.Sort(clientRule(), sortStrategy)
+	fmt.Printf("Cards sorted by %s:\n", sortStrategy)
+	fmt.Println(playerDeck.String())
+	return true
+}
 
-				rankStr, suitStr := parts[0], parts[1]
-				rank, err := models.StringToRank(rankStr)
-				if err != nil {
-					log.Printf("invalid rank: %v", err)
-					continue
-				}
+// chooseReading lets the player pick how a play with wild cards is read from a numbered menu of its legal readings.
+// It returns the equivalent play and the declared combination of the reading, nil for a play without wild cards.
+func chooseReading(playAttempt []models.Card) ([]models.Card, *models.Combination) {
+	rule := clientRule()
 
-				var suit models.Suit
-				switch strings.ToUpper(suitStr) {
-				case "S":
-					suit = models.Spade
-				case "H":
-					suit = models.Heart
-				case "D":
-					suit = models.Diamond
-				case "C":
-					suit = models.Club
-				default:
-					log.Printf("invalid suit: %s, must be S, H, D, or C", suitStr)
-					continue
-				}
-
-				if suit == models.Heart && rank == trumpRank {
-					log.Printf("wild card not allowed: %s", card.String())
-					continue
-				}
+	wild := false
+	for _, card := range playAttempt {
+		wild = wild || rule.IsWildCard(card)
+	}
+	if !wild {
+		return nil, nil
+	}
 
-				equivalent[i] = models.NewCard(suit, rank)
-				replaced = true
-			}
+	// the same cards read as a weaker combination of the same type are never worth playing,
+	// keep the strongest reading of each type, which comes first
+	var readings []models.Reading
+	seen := make(map[models.CombinationType]bool)
+	for _, reading := range rule.Readings(playAttempt) {
+		if !seen[reading.Type] {
+			seen[reading.Type] = true
+			readings = append(readings, reading)
 		}
 	}
-
-	return equivalent
+	switch len(readings) {
+	case 0:
+		fmt.Println("These cards form no combination, even with the wild cards")
+		return nil, nil
+	case 1:
+		fmt.Printf("Playing %s as %s\n", models.FormatCards(readings[0].Equivalent, notation), readings[0].Combination)
+		return readings[0].Equivalent, &readings[0].Combination
+	}
+	for {
+		fmt.Println("The wild cards can be read in more than one way:")
+		for i, reading := range readings {
+			fmt.Printf("%d) %s: %s\n", i, reading.Combination, models.FormatCards(reading.Equivalent, notation))
+		}
+		fmt.Println("pick the number of the reading to play:")
+		var input string
+		fmt.Scan(&input)
+		i, err := strconv.Atoi(input)
+		if err != nil || i < 0 || i >= len(readings) {
+			fmt.Println("Invalid choice. Please pick one of the numbers above")
+			continue
+		}
+		return readings[i].Equivalent, &readings[i].Combination
+	}
 }
 
 func main() {
 	flag.Parse()
 	log.SetFlags(0)
 
+	var err error
+	if notation, err = models.ParseNotation(*notationName); err != nil {
+		log.Fatal(err)
+	}
+	if sortStrategy, err = models.ParseSortStrategy(*sortName); err != nil {
+		log.Fatal(err)
+	}
+	beliefs = bot.NewBeliefs(profile, time.Now().UnixNano())
+
 	interrupt := make(chan os.Signal, 1)
 	signal.Notify(interrupt, os.Interrupt)
 
@@ -345,14 +567,17 @@ func main() {
 	}
 	defer conn.Close()
 
-	// Start reading messages from server
-	done := make(chan struct{})
+	// Read messages from the server in their own goroutine, so pings from the server
+	// are answered while the player is typing
+	messages := make(chan *models.ServerMessage, 16)
 	go func() {
-		defer close(done)
+		defer close(readerDone)
+		defer close(messages)
 		for {
 			_, message, err := conn.ReadMessage()
 			if err != nil {
 				log.Println("read:", err)
+				return
 			}
 			log.Printf("Received text message: %s", string(message))
 			msg, err := models.ParseServerMessage(message)
@@ -360,7 +585,19 @@ func main() {
 				log.Printf("Failed to parse message: %v", err)
 				continue
 			}
+			messages <- msg
+		}
+	}()
+
+	// Start handling messages from server
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for msg := range messages {
 			log.Printf("Received message: %v", msg)
+			if event, ok := bot.EventOf(msg, index); ok && *assist {
+				beliefs.Observe(event)
+			}
 			switch msg.Action {
 			case "availableSlots":
 				if err := selectAndJoinSlot(conn, msg.Data); err != nil {
@@ -368,7 +605,51 @@ func main() {
 					return
 				}
 			case "joinConfirm":
-				log.Printf("Joined successfully")
+				seatToken, team, _ := strings.Cut(msg.Data, ";")
+				log.Printf("Joined successfully in team %s", team)
+				if seatToken != "" {
+					*token = seatToken
+					fmt.Printf("Your seat token is %s, reconnect with -token %s to take your seat back\n", seatToken, seatToken)
+				}
+			case "rules":
+				rules, err := models.ParseRulesServerMessage(msg.Data)
+				if err != nil {
+					log.Printf("Failed to parse rules message: %v", err)
+					continue
+				}
+				fmt.Printf("House rules %s\n", rules)
+				profile = *rules
+				beliefs = bot.NewBeliefs(profile, time.Now().UnixNano())
+			case "swapRequested", "seatsSwapped":
+				from, fromTeam, fields, err := models.ParsePlayerServerMessage(msg.Data)
+				if err != nil || len(fields) != 2 {
+					log.Printf("Failed to parse %s message: %s", msg.Action, msg.Data)
+					continue
+				}
+				to, _ := strconv.Atoi(fields[0])
+				toTeam, _ := strconv.Atoi(fields[1])
+				if msg.Action == "swapRequested" {
+					fmt.Printf("Player %d (team %d) asks to swap seats with player %d (team %d)\n", from, fromTeam, to, toTeam)
+					if to == index {
+						fmt.Printf("Type S%d to accept\n", from)
+					}
+				} else {
+					fmt.Printf("Player at seat %d moved to seat %d (team %d)\n", from, to, toTeam)
+					switch index {
+					case from:
+						index = to
+						fmt.Printf("You are now at seat %d in team %d\n", to, toTeam)
+					case to:
+						index = from
+						fmt.Printf("You are now at seat %d in team %d\n", from, fromTeam)
+					}
+				}
+				if inLobby {
+					if err := handleAllJoined(conn); err != nil {
+						log.Printf("Error handling all joined: %v", err)
+						return
+					}
+				}
 			case "allJoined":
 				if err := handleAllJoined(conn); err != nil {
 					log.Printf("Error handling all joined: %v", err)
@@ -389,50 +670,182 @@ func main() {
 				playerDeck = deck
 				trumpRank = tRank
 				finishedIndexes = fIndexes
+				playerDeck.Sort(clientRule(), sortStrategy)
 				fmt.Println(deck.String())
 				fmt.Printf("Trump rank: %s\n", models.RankToString(trumpRank))
 				fmt.Printf("Finished indexes: %v\n", finishedIndexes)
+			case "tributeDue":
+				from, to, _, teams, err := models.ParseTributeServerMessage(msg.Data)
+				if err != nil {
+					log.Printf("Failed to parse tribute message: %v", err)
+					continue
+				}
+				if to == -1 {
+					fmt.Printf("Player %d (team %d) pays tribute\n", from, teams[0])
+				} else {
+					fmt.Printf("Player %d (team %d) pays tribute to player %d (team %d)\n", from, teams[0], to, teams[1])
+				}
+				if from == index {
+					promptCard(conn, "tribute")
+				}
+			case "revealed":
+				leader, team, fields, err := models.ParsePlayerServerMessage(msg.Data)
+				if err != nil || len(fields) != 1 {
+					log.Printf("Failed to parse revealed message: %s", msg.Data)
+					continue
+				}
+				card, err := models.ParseCard(fields[0])
+				if err != nil {
+					log.Printf("Failed to parse revealed card: %v", err)
+					continue
+				}
+				fmt.Printf("%s was turned face up, player %d (team %d) received its twin and leads the first round\n", models.FormatCard(card, notation), leader, team)
+			case "antiTribute":
+				fmt.Printf("Players %s hold every big joker, no tribute this round\n", msg.Data)
+			case "tributePaid", "cardReturned":
+				from, to, card, teams, err := models.ParseTributeServerMessage(msg.Data)
+				if err != nil || card == nil {
+					log.Printf("Failed to parse %s message: %s", msg.Action, msg.Data)
+					continue
+				}
+				fmt.Printf("Player %d (team %d) gave %s to player %d (team %d)\n", from, teams[0], models.FormatCard(*card, notation), to, teams[1])
+				if from == index {
+					pendingCard = ""
+					playerDeck.Play(*card)
+				}
+				if to == index {
+					playerDeck.Add(*card)
+					playerDeck.Sort(clientRule(), sortStrategy)
+					if msg.Action == "tributePaid" {
+						promptCard(conn, "return")
+					}
+				}
+			case "arrange":
 				organizeCards(conn)
-
 			case "play":
-				playerIndex, err := strconv.Atoi(msg.Data)
+				playerIndex, team, _, err := models.ParsePlayerServerMessage(msg.Data)
 				if err != nil {
 					log.Printf("Failed to parse index: %v", err)
 					return
 				}
-				fmt.Printf("Player %d's turn\n", playerIndex)
-				if index == playerIndex {
-					cards := getCardsFromIndexes()
-					if cards == nil {
-						conn.WriteMessage(websocket.TextMessage, models.BuildClientMessage(index, "pass", ""))
-					} else {
-						playAttempt = cards
-						equivalentAttempt = handleWildCards(cards, trumpRank)
-						conn.WriteMessage(websocket.TextMessage, models.BuildClientMessage(index, "playAttempt", models.ConstructClientPlayMessage(cards, playerDeck.Count()-len(playAttempt), equivalentAttempt)))
-					}
+				fmt.Printf("Player %d's turn (team %d)\n", playerIndex, team)
+				if index == playerIndex && *assist {
+					// the table state shows the assist before the prompt
+					conn.WriteMessage(websocket.TextMessage, models.BuildClientMessage(index, "state", ""))
+				} else if index == playerIndex {
+					promptPlay(conn)
 				}
 			case "invalidPlay":
-				fmt.Println("Invalid play, trying again")
-				cards := getCardsFromIndexes()
-				playAttempt = cards
-
-				equivalentAttempt = handleWildCards(cards, trumpRank)
-				conn.WriteMessage(websocket.TextMessage, models.BuildClientMessage(index, "playAttempt", models.ConstructClientPlayMessage(cards, playerDeck.Count()-len(playAttempt), equivalentAttempt)))
-
+				perr, err := models.ParseErrorServerMessage(msg.Data)
+				if err != nil {
+					log.Printf("Failed to parse invalid play message: %v", err)
+					continue
+				}
+				fmt.Printf("Invalid play (%s): %s\n", perr.Code, perr.Message)
+				if perr.Code == models.CodeNotYourTurn || perr.Code == models.CodeWrongPhase {
+					continue
+				}
+				fmt.Println("Trying again")
+				promptPlay(conn)
+			case "state":
+				state, err := models.ParseStateServerMessage(msg.Data)
+				if err != nil {
+					log.Printf("Failed to parse state message: %v", err)
+					continue
+				}
+				comingBack := playerDeck == nil && state.Hand != ""
+				if comingBack {
+					// we came back to a round in progress, take the hand from the snapshot
+					playerDeck, err = models.NewDeckFromString(state.Hand)
+					if err != nil {
+						log.Printf("Failed to parse hand: %v", err)
+						continue
+					}
+					if trumpRank, err = models.ParseRank(state.TrumpRank); err != nil {
+						log.Printf("Failed to parse trump rank: %v", err)
+					}
+				}
+				printState(state)
+				if comingBack && state.Phase == models.PhaseArranging {
+					organizeCards(conn)
+				}
+				if state.Phase == models.PhasePlaying && state.CurrentIndex == index {
+					promptPlay(conn)
+				}
+			case "away":
+				awayIndex, team, fields, err := models.ParsePlayerServerMessage(msg.Data)
+				if err != nil || len(fields) != 1 {
+					log.Printf("Failed to parse away message: %s", msg.Data)
+					continue
+				}
+				switch models.LeavePolicy(fields[0]) {
+				case models.LeaveBot:
+					fmt.Printf("Player %d (team %d) left, the server plays for them until they come back\n", awayIndex, team)
+				case models.LeaveForfeit:
+					fmt.Printf("Player %d (team %d) left, their team forfeits the round\n", awayIndex, team)
+				default:
+					fmt.Printf("Player %d (team %d) left, waiting for them to come back\n", awayIndex, team)
+				}
+			case "roundOver":
+				order, team, levelsUp, levels, err := models.ParseRoundOverServerMessage(msg.Data)
+				if err != nil {
+					log.Printf("Failed to parse round over message: %v", err)
+					continue
+				}
+				fmt.Printf("Round over, finishing order: %v\n", order)
+				levelStrs := make([]string, len(levels))
+				for t, level := range levels {
+					levelStrs[t] = fmt.Sprintf("team %d at %s", t, models.RankToString(level))
+				}
+				fmt.Printf("Team %d goes up %d levels, %s\n", team, levelsUp, strings.Join(levelStrs, ", "))
+			case "passed", "back", "leave":
+				playerIndex, team, _, err := models.ParsePlayerServerMessage(msg.Data)
+				if err != nil {
+					log.Printf("Failed to parse %s message: %v", msg.Action, err)
+					continue
+				}
+				what := map[string]string{"passed": "passed", "back": "is back", "leave": "left the table"}[msg.Action]
+				fmt.Printf("Player %d (team %d) %s\n", playerIndex, team, what)
+			case "finished":
+				finishedIndex, team, fields, err := models.ParsePlayerServerMessage(msg.Data)
+				if err != nil || len(fields) != 1 {
+					log.Printf("Failed to parse finished message: %s", msg.Data)
+					continue
+				}
+				fmt.Printf("Player %d (team %d) finished #%s\n", finishedIndex, team, fields[0])
+			case "matchOver":
+				fmt.Printf("Team %s won the match!\n", msg.Data)
+			case "error":
+				perr, err := models.ParseErrorServerMessage(msg.Data)
+				if err != nil {
+					log.Printf("Server error: %s", msg.Data)
+					continue
+				}
+				log.Printf("Server error (%s): %s", perr.Code, perr.Message)
+				if pendingCard != "" && (perr.Code == models.CodeIllegalTribute || perr.Code == models.CodeCardsNotInHand) {
+					fmt.Println("Trying again")
+					promptCard(conn, pendingCard)
+				} else if inLobby {
+					if err := handleAllJoined(conn); err != nil {
+						log.Printf("Error handling all joined: %v", err)
+						return
+					}
+				}
 			case "validPlay":
-				fmt.Println("Valid play")
-				playerDeck.PlayN(playAttempt)
-				fmt.Println(playerDeck.String())
-				msg := models.ConstructClientPlayMessage(playAttempt, playerDeck.Count(), equivalentAttempt)
-				conn.WriteMessage(websocket.TextMessage, models.BuildClientMessage(index, "play", msg))
+				fmt.Println("Valid selection")
+				promptPlay(conn)
 			case "lastPlay":
 				fmt.Println("Last play:")
-				playerIndex, numCardsLeft, attemptDeck, equivalentDeck, err := models.ParseLastPlayServerMessage(msg.Data)
+				playerIndex, team, numCardsLeft, attemptDeck, equivalentDeck, err := models.ParseLastPlayServerMessage(msg.Data)
 				if err != nil {
 					log.Printf("Failed to parse last play message: %v", err)
 					return
 				}
-				fmt.Printf("Player %d's last play:\n", playerIndex)
+				if playerIndex == index {
+					// the server accepted our play, remove the cards from our hand
+					playerDeck.PlayN(playAttempt)
+				}
+				fmt.Printf("Player %d's last play (team %d):\n", playerIndex, team)
 				fmt.Println(attemptDeck.String())
 				fmt.Printf("Number of cards left: %d\n", numCardsLeft)
 				fmt.Printf("Equivalent play:\n")
@@ -447,7 +860,7 @@ func main() {
 			return
 		case <-interrupt:
 			log.Println("Interrupt received, closing connection...")
-			err := conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			err := conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
 			if err != nil {
 				log.Println("write close:", err)
 			}
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ChengL-cisco/onlineGuanDan/pkg/models"
+)
+
+// conformanceCase is an example play and whether the tournament rules allow it
+type conformanceCase struct {
+	rule  string
+	cards string
+	legal bool
+	// aceHigh plays the case with aces that cannot be low
+	aceHigh bool
+}
+
+// conformanceCases covers each combination rule with legal and illegal examples
+var conformanceCases = []conformanceCase{
+	// straights are exactly 5 cards of consecutive ranks
+	{rule: "straight", cards: "3-S 4-H 5-D 6-C 7-S", legal: true},
+	{rule: "straight", cards: "10-S J-H Q-D K-C A-S", legal: true},
+	{rule: "straight", cards: "A-S 2-H 3-D 4-C 5-S", legal: true},
+	{rule: "straight", cards: "5-S 3-H A-D 2-C 4-S", legal: true},
+	{rule: "straight", cards: "3-S 4-H 5-D 6-C 7-S", legal: true, aceHigh: true},
+	{rule: "straight", cards: "A-S 2-H 3-D 4-C 5-S", legal: false, aceHigh: true},
+	{rule: "straight", cards: "J-S Q-H K-D A-C 2-S", legal: false},
+	{rule: "straight", cards: "Q-S K-H A-D 2-C 3-S", legal: false},
+	{rule: "straight", cards: "K-S A-H 2-D 3-C 4-S", legal: false},
+	{rule: "straight", cards: "Q-S K-H A-D Jr BJr", legal: false},
+	{rule: "straight", cards: "K-S A-H Jr BJr 2-S", legal: false},
+	{rule: "straight", cards: "3-S 4-H 5-D 6-C 8-S", legal: false},
+	{rule: "straight", cards: "3-S 4-H 5-D 6-C", legal: false},
+	{rule: "straight", cards: "3-S 4-H 5-D", legal: false},
+	{rule: "straight", cards: "3-S 4-H", legal: false},
+	{rule: "straight", cards: "3-S 4-H 5-D 6-C 7-S 8-H", legal: false},
+	{rule: "straight", cards: "3-S 4-H 5-D 6-C 7-S 8-H 9-D", legal: false},
+	{rule: "straight flush", cards: "9-H 10-H J-H Q-H K-H", legal: true},
+	{rule: "straight flush", cards: "A-S 2-S 3-S 4-S 5-S", legal: true},
+	{rule: "straight flush", cards: "J-S Q-S K-S A-S 2-S", legal: false},
+
+	// tubes are exactly 3 pairs of consecutive ranks
+	{rule: "tube", cards: "3-S 3-H 4-D 4-C 5-S 5-H", legal: true},
+	{rule: "tube", cards: "Q-S Q-H K-D K-C A-S A-H", legal: true},
+	{rule: "tube", cards: "A-S A-H 2-D 2-C 3-S 3-H", legal: true},
+	{rule: "tube", cards: "A-S A-H 2-D 2-C 3-S 3-H", legal: false, aceHigh: true},
+	{rule: "tube", cards: "K-S K-H A-D A-C 2-S 2-H", legal: false},
+	{rule: "tube", cards: "3-S 3-H 4-D 4-C 6-S 6-H", legal: false},
+	{rule: "tube", cards: "3-S 3-H 4-D 4-C", legal: false},
+	{rule: "tube", cards: "3-S 3-H 4-D 4-C 5-S 5-H 6-D 6-C", legal: false},
+	{rule: "tube", cards: "3-S 3-H 3-D 4-C 5-S 5-H", legal: false},
+	{rule: "tube", cards: "A-S A-H Jr Jr BJr BJr", legal: false},
+
+	// plates are exactly 2 triples of consecutive ranks
+	{rule: "plate", cards: "3-S 3-H 3-D 4-C 4-S 4-H", legal: true},
+	{rule: "plate", cards: "K-S K-H K-D A-C A-S A-H", legal: true},
+	{rule: "plate", cards: "A-S A-H A-D 2-C 2-S 2-H", legal: true},
+	{rule: "plate", cards: "A-S A-H A-D 2-C 2-S 2-H", legal: false, aceHigh: true},
+	{rule: "plate", cards: "3-S 3-H 3-D 5-C 5-S 5-H", legal: false},
+	{rule: "plate", cards: "3-S 3-H 3-D 4-C 4-S 4-H 5-D 5-C 5-S", legal: false},
+	{rule: "plate", cards: "A-S A-H A-D Jr Jr Jr", legal: false},
+
+	// other combinations
+	{rule: "pair", cards: "Jr Jr", legal: true},
+	{rule: "pair", cards: "Jr BJr", legal: false},
+	{rule: "full house", cards: "3-S 3-H 3-D 4-C 4-S", legal: true},
+	{rule: "full house", cards: "A-S A-H A-D Jr Jr", legal: true},
+	{rule: "bomb", cards: "7-S 7-H 7-D 7-C", legal: true},
+	{rule: "bomb", cards: "7-S 7-H 7-D 7-C 7-S 7-H 7-D", legal: true},
+}
+
+// runConformance checks every conformance case against the rules and returns the number of failed cases
+func runConformance() int {
+	info := &models.Info{}
+	info.SetTrumpRank(models.Two)
+	failed := 0
+	for _, c := range conformanceCases {
+		deck, err := models.NewDeckFromString(c.cards)
+		if err != nil {
+			fmt.Printf("FAIL %s %q: %v\n", c.rule, c.cards, err)
+			failed++
+			continue
+		}
+		profile := models.DefaultRuleProfile()
+		profile.AceLow = !c.aceHigh
+		rule := &models.Rule{}
+		rule.SetInfo(info)
+		rule.SetProfile(profile)
+		err = rule.CheckPlay(deck.GetCards())
+		if (err == nil) != c.legal {
+			fmt.Printf("FAIL %s %q: legal %t, got %v\n", c.rule, c.cards, c.legal, err)
+			failed++
+		}
+	}
+	fmt.Printf("%d of %d conformance cases passed\n", len(conformanceCases)-failed, len(conformanceCases))
+	return failed
+}
+
+// comparisonCase is an example of a play countered by another and whether the counter play beats it
+type comparisonCase struct {
+	rule    string
+	play    string
+	counter string
+	trump   models.Rank
+	beats   bool
+	// flushHigh plays the case with straight flushes above every bomb
+	flushHigh bool
+}
+
+// comparisonCases covers the comparison of each combination type with examples
+var comparisonCases = []comparisonCase{
+	// the trump rank beats the ace in singles, pairs, triples, full houses and bombs
+	{rule: "single", play: "A-S", counter: "5-D", trump: models.Five, beats: true},
+	{rule: "single", play: "5-D", counter: "A-S", trump: models.Five, beats: false},
+	{rule: "single", play: "5-D", counter: "Jr", trump: models.Five, beats: true},
+	{rule: "pair", play: "A-S A-H", counter: "5-D 5-C", trump: models.Five, beats: true},
+	{rule: "triple", play: "K-S K-H K-D", counter: "3-D 3-C 3-S", trump: models.Three, beats: true},
+	{rule: "full house", play: "A-S A-H A-D 4-C 4-S", counter: "5-D 5-C 5-S 3-C 3-S", trump: models.Five, beats: true},
+	{rule: "full house", play: "9-S 9-H 9-D 4-C 4-S", counter: "8-D 8-C 8-S A-C A-S", trump: models.Two, beats: false},
+	{rule: "bomb", play: "A-S A-H A-D A-C", counter: "5-D 5-C 5-S 5-H", trump: models.Five, beats: true},
+
+	// the trump rank keeps its natural place in runs
+	{rule: "straight", play: "9-S 10-H J-D Q-C K-S", counter: "10-S J-H Q-D K-C A-S", trump: models.King, beats: true},
+	{rule: "straight", play: "10-S J-H Q-D K-C A-S", counter: "9-S 10-H J-D Q-C K-S", trump: models.King, beats: false},
+	{rule: "straight", play: "A-S 2-H 3-D 4-C 5-S", counter: "2-S 3-H 4-D 5-C 6-S", trump: models.Two, beats: true},
+	{rule: "straight", play: "2-S 3-H 4-D 5-C 6-S", counter: "A-S 2-H 3-D 4-C 5-S", trump: models.Two, beats: false},
+	{rule: "tube", play: "7-S 7-H 8-D 8-C 9-S 9-H", counter: "A-S A-H 2-D 2-C 3-S 3-H", trump: models.Two, beats: false},
+	{rule: "tube", play: "A-S A-H 2-D 2-C 3-S 3-H", counter: "2-S 2-H 3-D 3-C 4-S 4-H", trump: models.Four, beats: true},
+	{rule: "plate", play: "Q-S Q-H Q-D K-C K-S K-H", counter: "K-S K-H K-D A-C A-S A-H", trump: models.Queen, beats: true},
+	{rule: "straight flush", play: "9-H 10-H J-H Q-H K-H", counter: "10-S J-S Q-S K-S A-S", trump: models.King, beats: true},
+
+	// plays of different types or sizes only beat each other with bombs
+	{rule: "mismatch", play: "3-S 3-H 4-D 4-C 5-S 5-H", counter: "3-S 3-H 3-D 4-C 4-S 4-H", trump: models.Two, beats: false},
+	{rule: "mismatch", play: "3-S 4-H 5-D 6-C 7-S", counter: "8-S 8-H 8-D 9-C 9-S", trump: models.Two, beats: false},
+	{rule: "bomb", play: "3-S 3-H 4-D 4-C 5-S 5-H", counter: "2-S 2-H 2-D 2-C", trump: models.Ten, beats: true},
+	{rule: "bomb", play: "K-S K-H K-D K-C", counter: "2-S 2-H 2-D 2-C 2-S", trump: models.Ten, beats: true},
+	{rule: "bomb", play: "9-S 9-H 9-D 9-C 9-S", counter: "3-H 4-H 5-H 6-H 7-H", trump: models.Ten, beats: true},
+	{rule: "bomb", play: "3-H 4-H 5-H 6-H 7-H", counter: "2-S 2-H 2-D 2-C 2-S 2-H", trump: models.Ten, beats: true},
+	{rule: "bomb", play: "2-S 2-H 2-D 2-C 2-S 2-H", counter: "3-H 4-H 5-H 6-H 7-H", trump: models.Ten, beats: false},
+	{rule: "bomb", play: "2-S 2-H 2-D 2-C 2-S 2-H", counter: "3-H 4-H 5-H 6-H 7-H", trump: models.Ten, beats: true, flushHigh: true},
+	{rule: "bomb", play: "3-H 4-H 5-H 6-H 7-H", counter: "2-S 2-H 2-D 2-C 2-S 2-H 2-D", trump: models.Ten, beats: false, flushHigh: true},
+	{rule: "bomb", play: "3-S 4-S 5-S 6-S 7-S", counter: "3-S 3-H 4-D 4-C 5-S 5-H", trump: models.Ten, beats: false},
+}
+
+// runComparisons checks every comparison case against the rules and returns the number of failed cases
+func runComparisons() int {
+	failed := 0
+	for _, c := range comparisonCases {
+		play, err := models.NewDeckFromString(c.play)
+		if err != nil {
+			fmt.Printf("FAIL %s %q: %v\n", c.rule, c.play, err)
+			failed++
+			continue
+		}
+		counter, err := models.NewDeckFromString(c.counter)
+		if err != nil {
+			fmt.Printf("FAIL %s %q: %v\n", c.rule, c.counter, err)
+			failed++
+			continue
+		}
+		info := &models.Info{}
+		info.SetTrumpRank(c.trump)
+		profile := models.DefaultRuleProfile()
+		if c.flushHigh {
+			profile.BombOrder = models.BombOrderFlushHigh
+		}
+		rule := &models.Rule{}
+		rule.SetInfo(info)
+		rule.SetProfile(profile)
+		if beats := rule.IsCounterPlayValid(play.GetCards(), counter.GetCards()); beats != c.beats {
+			fmt.Printf("FAIL %s %q against %q: beats %t, got %t\n", c.rule, c.counter, c.play, c.beats, beats)
+			failed++
+		}
+	}
+	fmt.Printf("%d of %d comparison cases passed\n", len(comparisonCases)-failed, len(comparisonCases))
+	return failed
+}
+
+// readingCase is an example play with wild cards and whether it can be read as the declared combination
+type readingCase struct {
+	cards    string
+	trump    models.Rank
+	declared string
+	legal    bool
+}
+
+// readingCases covers the declaration of combinations for plays with wild cards
+var readingCases = []readingCase{
+	{cards: "2-H 3-S 3-D 4-C 4-S", trump: models.Two, declared: "fullHouse:4", legal: true},
+	{cards: "2-H 3-S 3-D 4-C 4-S", trump: models.Two, declared: "fullHouse:3", legal: true},
+	{cards: "2-H 3-S 3-D 4-C 4-S", trump: models.Two, declared: "straight:6", legal: false},
+	{cards: "2-H 3-S 4-D 5-C 6-S", trump: models.Two, declared: "straight:6", legal: true},
+	{cards: "2-H 3-S 4-D 5-C 6-S", trump: models.Two, declared: "straight:7", legal: true},
+	{cards: "2-H 3-S 4-D 5-C 6-S", trump: models.Two, declared: "straight:5", legal: false},
+	{cards: "2-H 3-S 4-D 5-C 6-S", trump: models.Two, declared: "straightFlush:7", legal: false},
+	{cards: "2-H 3-S 4-S 5-S 6-S", trump: models.Two, declared: "straightFlush:7", legal: true},
+	{cards: "2-H 2-H 9-S 9-D", trump: models.Two, declared: "bomb:9", legal: true},
+	{cards: "2-H 9-S", trump: models.Two, declared: "pair:9", legal: true},
+	{cards: "2-H Jr", trump: models.Two, declared: "pair:Jr", legal: false},
+	{cards: "2-H", trump: models.Two, declared: "single:2", legal: true},
+}
+
+// runReadings checks every reading case against the rules and returns the number of failed cases
+func runReadings() int {
+	failed := 0
+	for _, c := range readingCases {
+		deck, err := models.NewDeckFromString(c.cards)
+		if err != nil {
+			fmt.Printf("FAIL %q: %v\n", c.cards, err)
+			failed++
+			continue
+		}
+		declared, err := models.ParseCombination(c.declared, deck.Count())
+		if err != nil {
+			fmt.Printf("FAIL %q as %s: %v\n", c.cards, c.declared, err)
+			failed++
+			continue
+		}
+		info := &models.Info{}
+		info.SetTrumpRank(c.trump)
+		rule := &models.Rule{}
+		rule.SetInfo(info)
+		legal := false
+		for _, reading := range rule.Readings(deck.GetCards()) {
+			legal = legal || reading.Combination == declared
+		}
+		if legal != c.legal {
+			fmt.Printf("FAIL %q as %s: legal %t, got %t\n", c.cards, c.declared, c.legal, legal)
+			failed++
+		}
+	}
+	fmt.Printf("%d of %d reading cases passed\n", len(readingCases)-failed, len(readingCases))
+	return failed
+}
+
+// notationCase is a card written in some notation and the card it stands for in the protocol notation,
+// empty if it is not a card
+type notationCase struct {
+	input string
+	card  string
+}
+
+// notationCases covers the card notations ParseCard reads
+var notationCases = []notationCase{
+	{input: "10-H", card: "10-H"},
+	{input: "A-S#2", card: "A-S#2"},
+	{input: "10H", card: "10-H"},
+	{input: "TH", card: "10-H"},
+	{input: "th", card: "10-H"},
+	{input: "h10", card: "10-H"},
+	{input: "♥10", card: "10-H"},
+	{input: "10♥", card: "10-H"},
+	{input: "红桃10", card: "10-H"},
+	{input: "红心A", card: "A-H"},
+	{input: "黑桃K", card: "K-S"},
+	{input: "方片2", card: "2-D"},
+	{input: "梅花Q", card: "Q-C"},
+	{input: "SJ", card: "Jr"},
+	{input: "BJ", card: "BJr"},
+	{input: "jr", card: "Jr"},
+	{input: "小王", card: "Jr"},
+	{input: "大王", card: "BJr"},
+	{input: "S-J", card: "J-S"},
+	{input: "JS", card: "J-S"},
+	{input: "1H", card: ""},
+	{input: "11-S", card: ""},
+	{input: "KX", card: ""},
+	{input: "A-S#0", card: ""},
+}
+
+// handCase is a hand written as a compact string and the cards it stands for in the protocol notation,
+// cards without a suit hint written with their rank only
+type handCase struct {
+	input string
+	cards string
+}
+
+// handCases covers the compact hand notation ParseHand reads
+var handCases = []handCase{
+	{input: "KKK22", cards: "K K K 2 2"},
+	{input: "KsKhKd2♠2♣", cards: "K-S K-H K-D 2-S 2-C"},
+	{input: "T J Q K A", cards: "10 J Q K A"},
+	{input: "10JQKA", cards: "10 J Q K A"},
+	{input: "KSJ", cards: "K Jr"},
+	{input: "SJBJ", cards: "Jr BJr"},
+	{input: "小王大王", cards: "Jr BJr"},
+	{input: "KKX", cards: ""},
+}
+
+// runNotations checks every notation and hand case, and that each card reads back from each notation,
+// and returns the number of failed cases
+func runNotations() int {
+	failed, total := 0, 0
+	for _, c := range notationCases {
+		total++
+		card, err := models.ParseCard(c.input)
+		got := ""
+		if err == nil {
+			got = card.CardString()
+		}
+		if got != c.card {
+			fmt.Printf("FAIL card %q: want %q, got %q (%v)\n", c.input, c.card, got, err)
+			failed++
+		}
+	}
+	for _, c := range handCases {
+		total++
+		cards, err := models.ParseHand(c.input)
+		var strs []string
+		for _, card := range cards {
+			if card.Suit == "" && card.Rank <= models.Ace {
+				strs = append(strs, models.RankToString(card.Rank))
+			} else {
+				strs = append(strs, card.CardString())
+			}
+		}
+		if got := strings.Join(strs, " "); got != c.cards || (err == nil) != (c.cards != "") {
+			fmt.Printf("FAIL hand %q: want %q, got %q (%v)\n", c.input, c.cards, got, err)
+			failed++
+		}
+	}
+	for _, notation := range []models.Notation{models.NotationProtocol, models.NotationCompact, models.NotationSymbol, models.NotationChinese} {
+		total++
+		for _, card := range models.NewDeck(1).GetCards() {
+			written := models.FormatCard(card, notation)
+			if read, err := models.ParseCard(written); err != nil || !read.SameValue(card) {
+				fmt.Printf("FAIL %s notation: %s written as %q reads back as %s (%v)\n", notation, card.CardString(), written, read.CardString(), err)
+				failed++
+				break
+			}
+		}
+	}
+	fmt.Printf("%d of %d notation cases passed\n", total-failed, total)
+	return failed
+}
+
+// sortCase is a hand and the order a sort strategy puts it in, in the protocol notation
+type sortCase struct {
+	strategy models.SortStrategy
+	cards    string
+	trump    models.Rank
+	sorted   string
+	// aceHigh plays the case without aces low in straights
+	aceHigh bool
+}
+
+// sortCases covers the sort strategies of the rules
+var sortCases = []sortCase{
+	{strategy: models.SortByStrength, cards: "3-S Jr 2-S A-D 2-H BJr 5-C", trump: models.Two, sorted: "2-H BJr Jr 2-S A-D 5-C 3-S"},
+	{strategy: models.SortByStrength, cards: "K-D K-S 10-C 10-H A-C", trump: models.Ten, sorted: "10-H 10-C A-C K-S K-D"},
+	{strategy: models.SortBySuit, cards: "3-D A-S Jr 3-S K-H 2-H 7-C 2-C", trump: models.Two, sorted: "2-H Jr A-S 3-S K-H 2-C 7-C 3-D"},
+	{strategy: models.SortByCombination, cards: "3-S 9-C 9-D 4-S 9-H 5-S 9-S 6-S 7-S 2-H BJr", trump: models.Two, sorted: "2-H 9-S 9-H 9-C 9-D 7-S 6-S 5-S 4-S 3-S BJr"},
+	{strategy: models.SortByCombination, cards: "8-D 8-C 8-S 8-H 8-D Q-C Q-D Q-H Q-S", trump: models.Two, sorted: "8-S 8-H 8-C 8-D 8-D Q-S Q-H Q-C Q-D"},
+	{strategy: models.SortByCombination, cards: "A-C 2-C 3-C 4-C 5-C K-D", trump: models.Ten, sorted: "5-C 4-C 3-C 2-C A-C K-D"},
+	{strategy: models.SortByCombination, cards: "A-C 2-C 3-C 4-C 5-C K-D", trump: models.Ten, sorted: "A-C K-D 5-C 4-C 3-C 2-C", aceHigh: true},
+}
+
+// runSorts checks every sort case against the rules and returns the number of failed cases
+func runSorts() int {
+	failed := 0
+	for _, c := range sortCases {
+		deck, err := models.NewDeckFromString(c.cards)
+		if err != nil {
+			fmt.Printf("FAIL %s %q: %v\n", c.strategy, c.cards, err)
+			failed++
+			continue
+		}
+		info := &models.Info{}
+		info.SetTrumpRank(c.trump)
+		profile := models.DefaultRuleProfile()
+		profile.AceLow = !c.aceHigh
+		rule := &models.Rule{}
+		rule.SetInfo(info)
+		rule.SetProfile(profile)
+		deck.Sort(rule, c.strategy)
+		if sorted := models.FormatCards(deck.GetCards(), models.NotationProtocol); sorted != c.sorted {
+			fmt.Printf("FAIL %s %q: want %q, got %q\n", c.strategy, c.cards, c.sorted, sorted)
+			failed++
+		}
+	}
+	fmt.Printf("%d of %d sort cases passed\n", len(sortCases)-failed, len(sortCases))
+	return failed
+}
@@ -2,12 +2,13 @@ package main
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/ChengL-cisco/onlineGuanDan/pkg/models"
 )
 
 func main() {
-	attemptDeck, numCardsLeft, equivalentDeck, err := models.ParseClientPlayMessage("K-H K-C K-D A-H A-C A-D;21;")
+	attemptDeck, numCardsLeft, equivalentDeck, declared, err := models.ParseClientPlayMessage("K-H K-C K-D A-H A-C A-D;21;;plate:A")
 	if err != nil {
 		fmt.Println(err)
 		return
@@ -15,4 +16,9 @@ func main() {
 	fmt.Println(attemptDeck.String())
 	fmt.Println(equivalentDeck.String())
 	fmt.Println(numCardsLeft)
+	fmt.Println(declared)
+
+	if runConformance()+runComparisons()+runReadings()+runNotations()+runSorts() > 0 {
+		os.Exit(1)
+	}
 }
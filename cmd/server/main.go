@@ -1,20 +1,34 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"log"
-	"math/rand"
 	"net/http"
-	"sort"
+	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/ChengL-cisco/onlineGuanDan/pkg/bot"
+	"github.com/ChengL-cisco/onlineGuanDan/pkg/game"
 	"github.com/ChengL-cisco/onlineGuanDan/pkg/models"
 	"github.com/gorilla/websocket"
 )
 
+const (
+	// writeWait is the time allowed to write a message to the client
+	writeWait = 10 * time.Second
+	// pongWait is the time allowed to read the next pong message from the client
+	pongWait = 60 * time.Second
+	// pingPeriod is the period of pings sent to the client, it must be less than pongWait
+	pingPeriod = (pongWait * 9) / 10
+	// maxMessageSize is the maximum message size allowed from the client
+	maxMessageSize = 4096
+)
+
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
@@ -28,14 +42,12 @@ type Client struct {
 }
 
 // Hub maintains the set of active clients
-var deck models.DeckAPI
 var (
-	info       = &models.Info{}
-	rule       = &models.Rule{}
-	clients    = make(map[int]*Client) // Map of player index to Client
-	broadcast  = make(chan []byte)     // Broadcast channel
-	mutex      = &sync.Mutex{}         // Mutex to protect clients map
-	firstRound = true
+	engine  *game.Engine
+	clients = make(map[int]*Client)  // Map of player index to Client
+	bots    []*bot.Seat              // seats played by bots on the server
+	botWake = make(chan struct{}, 1) // wakes playBots after a command was handled
+	mutex   = &sync.Mutex{}          // Mutex to protect the engine and clients map
 )
 
 // handleWebSocket handles WebSocket requests from clients
@@ -55,254 +67,498 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("New client connected.")
 	// Get and send available slots to the client
-	availableSlots := getAvailableSlots()
-	client.conn.WriteMessage(websocket.TextMessage, models.BuildServerMessage("availableSlots", availableSlots))
+	mutex.Lock()
+	sendAvailableSlots(client)
+	mutex.Unlock()
 
-	// Start goroutine for reading messages
+	// Start goroutines for writing and reading messages
+	go client.writePump()
 	go client.processClientMsg()
 }
 
-// getAvailableSlots returns a space-separated string of available slot numbers
-func getAvailableSlots() string {
-	mutex.Lock()
-	defer mutex.Unlock()
-
-	availableSlots := info.GetAvailableSlots()
-	keys := make([]int, 0, len(availableSlots))
-	for k := range availableSlots {
-		keys = append(keys, k)
+// sendAvailableSlots sends the client the available slot numbers with the team of each slot,
+// including the seats kept for away players, which can only be taken back with their token
+func sendAvailableSlots(c *Client) {
+	slots := engine.AvailableSlots()
+	teams := make([]int, len(slots))
+	for i, slot := range slots {
+		teams[i] = teamOf(slot)
 	}
-	sort.Ints(keys)
+	c.write(models.BuildServerMessage("availableSlots", models.ConstructAvailableSlotsServerMessage(slots, teams)))
+}
 
-	// Join the sorted keys into a space-separated string
-	msg := ""
-	for _, k := range keys {
-		msg += fmt.Sprintf("%d ", k)
+// teamOf returns the team of the seat at index, or -1 for game.Everybody
+func teamOf(index int) int {
+	if index == game.Everybody {
+		return -1
 	}
-	return strings.TrimSpace(msg) // Remove trailing space
+	return engine.Info().GetTeamOf(index)
 }
 
-// processClientMsg processes messages from the WebSocket connection
+// processClientMsg reads and processes messages from the WebSocket connection until it fails.
+// The read deadline is extended by every pong, so a client that stops answering pings
+// makes the read fail and ends the loop.
 func (c *Client) processClientMsg() {
 	defer func() {
-		mutex.Lock()
-		info.GetAvailableSlots()[c.Index] = true
-		delete(info.GetNames(), c.Index)
-		delete(clients, c.Index)
-		mutex.Unlock()
-
+		handleDisconnect(c)
 		c.conn.Close()
 		log.Printf("Client disconnected.")
 	}()
 
+	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		c.conn.SetReadDeadline(time.Now().Add(pongWait))
+		return nil
+	})
+
 	for {
-		log.Printf("Waiting for message...")
 		messageType, message, err := c.conn.ReadMessage()
-		log.Printf("Received message: %v", string(message))
 		if err != nil {
-			// Handle the error, which might be a CloseError
-			if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
-				// The client initiated a normal closure
-				fmt.Println("Client closed connection with normal closure")
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Printf("Client %d closed connection with normal closure", c.Index)
 			} else {
-				// Some other error occurred
-				fmt.Println("Error reading from client:", err)
+				log.Printf("Error reading from client %d: %v", c.Index, err)
 			}
+			return
+		}
+		log.Printf("Received message: %v", string(message))
+		if messageType != websocket.TextMessage {
+			log.Println("Received unknown message from client")
+			continue
+		}
+
+		msg, err := models.ParseClientMessage(message)
+		if err != nil {
+			log.Printf("Failed to parse message: %v", err)
+			sendError(c, "error", models.NewProtocolError(models.CodeMalformed, "Failed to parse message: %v", err))
+			continue
 		}
 
-		switch messageType {
-		case websocket.TextMessage:
-			// Handle text message
-			msg, err := models.ParseClientMessage(message)
+		switch msg.Action {
+		case "join":
+			log.Printf("Client %s wants to join", msg.Data)
+			handleCommand(c, game.Join{Index: msg.Index, Name: msg.Data, Token: msg.Token})
+		case "ready":
+			log.Printf("Client %s is ready", msg.Data)
+			handleCommand(c, game.Ready{Index: c.Index})
+		case "start":
+			log.Printf("Client %s started", msg.Data)
+			handleCommand(c, game.Start{Index: c.Index})
+		case "play":
+			attemptDeck, _, equivalentDeck, declared, err := models.ParseClientPlayMessage(msg.Data)
 			if err != nil {
-				log.Printf("Failed to parse message: %v", err)
-				c.conn.WriteMessage(websocket.TextMessage, models.BuildServerMessage("error", fmt.Sprintf("Failed to parse message: %v", err)))
+				log.Printf("Failed to parse play message: %v", err)
+				sendError(c, "invalidPlay", models.NewProtocolError(models.CodeMalformed, "Failed to parse play message: %v", err))
 				continue
 			}
+			handleCommand(c, game.Play{
+				Index:      c.Index,
+				Cards:      attemptDeck.GetCards(),
+				Equivalent: equivalentDeck.GetCards(),
+				Declared:   declared,
+				DryRun:     msg.DryRun,
+			})
+		case "tribute", "return":
+			log.Printf("Client %d %s %s", c.Index, msg.Action, msg.Data)
+			card, err := models.ParseCard(msg.Data)
+			if err != nil {
+				log.Printf("Failed to parse %s message: %v", msg.Action, err)
+				sendError(c, "error", models.NewProtocolError(models.CodeMalformed, "Failed to parse card: %v", err))
+				continue
+			}
+			if msg.Action == "tribute" {
+				handleCommand(c, game.Tribute{Index: c.Index, Card: card})
+			} else {
+				handleCommand(c, game.Return{Index: c.Index, Card: card})
+			}
+		case "team", "swap":
+			log.Printf("Client %d asks for %s %s", c.Index, msg.Action, msg.Data)
+			n, err := strconv.Atoi(msg.Data)
+			if err != nil {
+				sendError(c, "error", models.NewProtocolError(models.CodeMalformed, "Failed to parse %s: %v", msg.Action, err))
+				continue
+			}
+			if msg.Action == "team" {
+				handleCommand(c, game.ChooseTeam{Index: c.Index, Team: n})
+			} else {
+				handleCommand(c, game.SwapSeats{Index: c.Index, With: n})
+			}
+		case "pass":
+			handleCommand(c, game.Pass{Index: c.Index})
+		case "state":
+			mutex.Lock()
+			sendState(c)
+			mutex.Unlock()
+		case "leave":
+			log.Printf("Client %d left", c.Index)
+			handleCommand(c, game.Leave{Index: c.Index})
+		default:
+			log.Printf("Unknown action: %s", msg.Action)
+		}
+	}
+}
+
+// writePump sends queued messages to the WebSocket connection and pings the client periodically.
+// It is the only goroutine writing data messages to the connection.
+func (c *Client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case message, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				// the read loop ended, close the connection
+				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				log.Printf("Error writing to client %d: %v", c.Index, err)
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				log.Printf("Error pinging client %d: %v", c.Index, err)
+				return
+			}
+		}
+	}
+}
 
-			switch msg.Action {
-			case "join":
-				log.Printf("Client %s wants to join", msg.Data)
-				mutex.Lock()
-				availableSlots := info.GetAvailableSlots()
-				log.Printf("availableSlots: %v", availableSlots)
-
-				if _, exists := availableSlots[msg.Index]; !exists {
-					// slot no longer available
-					mutex.Unlock()
-					c.conn.WriteMessage(websocket.TextMessage, models.BuildServerMessage("availableSlots", getAvailableSlots()))
-				} else {
-					c.Index = msg.Index
-					clients[msg.Index] = c
-					delete(availableSlots, msg.Index)
-					names := info.GetNames()
-					names[msg.Index] = msg.Data
-					info.SetNames(names)
-
-					c.conn.WriteMessage(websocket.TextMessage, models.BuildServerMessage("joinConfirm", ""))
-
-					// to do: if everybody joined, broadcast to ready to start
-					if len(clients) == info.GetNumPlayers() {
-						log.Printf("Everybody joined, getting ready...")
-						broadcastMessage(models.BuildServerMessage("allJoined", ""))
-					}
-					mutex.Unlock()
-				}
+// write queues a message to be sent to the client, dropping it if the client is not keeping up
+func (c *Client) write(message []byte) {
+	select {
+	case c.send <- message:
+	default:
+		log.Printf("Send buffer of client %d is full, dropping message", c.Index)
+	}
+}
 
-			case "ready":
-				log.Printf("Client %s is ready", msg.Data)
-				mutex.Lock()
-				info.GetReadyToStartMap()[msg.Index] = true
-				// if everybody is ready, send out the cards
-				if len(info.GetReadyToStartMap()) == info.GetNumPlayers() {
-					log.Printf("Everybody is ready, starting the game...")
-					info.SetIsRoundInSession(true)
-					if firstRound {
-						rand.Seed(time.Now().UnixNano())
-						info.SetCurrentPlayerIndex(rand.Intn(info.GetNumPlayers()))
-						info.SetTrumpRank(models.Two)
-						firstRound = false
-					}
-					// reset ready to start map
-					info.SetReadyToStartMap(make(map[int]bool))
-
-					deck = models.NewDeck(models.NumOfDecks(info.GetNumPlayers()))
-					// for testing
-					deck = deck.Split(2)[0]
-
-					decks := deck.Split(info.GetNumPlayers())
-					for index, deck := range decks {
-						deck.Sort(info.GetTrumpRank())
-						clients[index].conn.WriteMessage(websocket.TextMessage, models.BuildServerMessage("startRound", models.ConstructStartRoundServerMessage(deck, info)))
-					}
+// isSeated returns true if the client has taken a seat at the table
+func (c *Client) isSeated() bool {
+	return clients[c.Index] == c
+}
 
+// handleDisconnect makes the client leave its seat after the connection ended
+func handleDisconnect(c *Client) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	defer close(c.send)
+
+	if !c.isSeated() {
+		return
+	}
+	delete(clients, c.Index)
+	if _, err := engine.Handle(game.Leave{Index: c.Index}); err != nil {
+		log.Printf("Failed to remove client %d: %v", c.Index, err)
+	}
+	wakeBots()
+}
+
+// handleCommand hands a command from the client to the game engine, which notifies notifyClients of the events.
+// A refused join is answered with the available slots, a refused play or pass with invalidPlay
+// and any other refused command with error.
+func handleCommand(c *Client, cmd game.Command) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	join, isJoin := cmd.(game.Join)
+	if isJoin && c.isSeated() {
+		sendError(c, "error", models.NewProtocolError(models.CodeWrongPhase, "already seated at %d", c.Index))
+		return
+	}
+	if !isJoin && !c.isSeated() {
+		sendError(c, "error", models.NewProtocolError(models.CodeNotSeated, "join a seat first"))
+		return
+	}
+
+	// the client takes its place before the engine reports the join, and leaves before the seat is freed
+	switch cmd.(type) {
+	case game.Join:
+		if _, taken := clients[join.Index]; !taken {
+			c.Index = join.Index
+			clients[join.Index] = c
+		}
+	case game.Leave:
+		delete(clients, c.Index)
+	}
+
+	_, err := engine.Handle(cmd)
+	if err != nil {
+		if isJoin && c.isSeated() {
+			delete(clients, c.Index)
+		}
+		var perr *models.ProtocolError
+		if !errors.As(err, &perr) {
+			perr = models.NewProtocolError(models.CodeMalformed, "%v", err)
+		}
+		log.Printf("Refused %T from client %d: %v", cmd, c.Index, perr)
+		switch cmd.(type) {
+		case game.Join:
+			sendAvailableSlots(c)
+		case game.Play, game.Pass:
+			sendError(c, "invalidPlay", perr)
+		default:
+			sendError(c, "error", perr)
+		}
+		return
+	}
+	wakeBots()
+}
+
+// wakeBots lets playBots answer the engine once the lock is released
+func wakeBots() {
+	select {
+	case botWake <- struct{}{}:
+	default:
+	}
+}
+
+// playBots lets the bot seats answer the engine whenever it is woken, until the engine waits for none of them.
+// The bots decide without holding the lock, so a bot searching its play does not keep the clients waiting,
+// and their answers are handled like the commands of the clients.
+func playBots() {
+	for range botWake {
+		for {
+			mutex.Lock()
+			var seat *bot.Seat
+			var decide func() game.Command
+			for _, s := range bots {
+				if d, ok := s.Decide(); ok {
+					seat, decide = s, d
+					break
 				}
-				mutex.Unlock()
-			case "start":
-				log.Printf("Client %s started", msg.Data)
-				mutex.Lock()
-				info.GetReadyToPlay()[msg.Index] = true
-				// if everybody is ready, start the round
-				if len(info.GetReadyToPlay()) == info.GetNumPlayers() {
-					log.Printf("Everybody is ready, starting the round...")
-					// reset ready to play map
-					info.SetReadyToPlay(make(map[int]bool))
-					broadcastMessage(models.BuildServerMessage("play", fmt.Sprintf("%d", info.GetCurrentPlayerIndex())))
-				}
-				mutex.Unlock()
-			case "playAttempt":
-				cards, _, equivalentAttempt, err := models.ParseClientPlayMessage(msg.Data)
-				if err != nil {
-					log.Printf("Failed to parse play message: %v", err)
-					return
-				}
-				fmt.Println(cards.String())
-				fmt.Println(equivalentAttempt.String())
-				valid := false
-				if info.GetLastPlayedCards() == nil || info.GetLastPlayedIndex() == msg.Index {
-					if rule.IsPlayValid(equivalentAttempt.GetCards()) {
-						valid = true
-					}
-				} else {
-					if rule.IsCounterPlayValid(info.GetLastPlayedCards(), equivalentAttempt.GetCards()) {
-						valid = true
-					}
-				}
-				if valid {
-					log.Printf("valid play")
-					c.conn.WriteMessage(websocket.TextMessage, models.BuildServerMessage("validPlay", fmt.Sprintf("%d", msg.Index)))
-				} else {
-					log.Printf("invalid play")
-					c.conn.WriteMessage(websocket.TextMessage, models.BuildServerMessage("invalidPlay", fmt.Sprintf("%d", msg.Index)))
-				}
-			case "play":
-				log.Printf("Client played")
-				attemptDeck, numCardsLeft, equivalentDeck, err := models.ParseClientPlayMessage(msg.Data)
-				if err != nil {
-					log.Printf("Failed to parse play message: %v", err)
-					return
-				}
-				fmt.Println(attemptDeck.String())
-				fmt.Println(equivalentDeck.String())
-				info.SetLastPlayedCards(equivalentDeck.GetCards())
-				info.SetLastPlayedIndex(c.Index)
-				info.SetCurrentPlayerIndex((info.GetCurrentPlayerIndex() + 1) % info.GetNumPlayers())
-				broadcastMessage(models.BuildServerMessage("lastPlay", fmt.Sprintf("%d", c.Index)+";"+fmt.Sprintf("%d", numCardsLeft)+";"+models.CardsString(attemptDeck.GetCards())+";"+models.CardsString(equivalentDeck.GetCards())))
-				if numCardsLeft == 0 {
-					fmt.Println("Index ", c.Index, " finished")
-					info.SetFinishedIndexes(append(info.GetFinishedIndexes(), c.Index))
-					if len(info.GetFinishedIndexes()) == info.GetNumPlayers()-1 {
-						fmt.Println("Everybody finished, calculating...")
-						// do calculation and broadcast result
-						info.SetIsRoundInSession(false)
-						broadcastMessage(models.BuildServerMessage("allJoined", ""))
-						// to do: reset game
-					}
-				}
-				broadcastMessage(models.BuildServerMessage("play", fmt.Sprintf("%d", info.GetCurrentPlayerIndex())))
-
-			case "tribute":
-				log.Printf("Client %s tributed", msg.Data)
-			case "return":
-				log.Printf("Client %d returned", msg.Index)
-			case "pass":
-				log.Printf("Client %d passed", msg.Index)
-				info.SetCurrentPlayerIndex((info.GetCurrentPlayerIndex() + 1) % info.GetNumPlayers())
-				broadcastMessage(models.BuildServerMessage("play", fmt.Sprintf("%d", info.GetCurrentPlayerIndex())))
-			case "leave":
-				log.Printf("Client %d left", msg.Index)
-			default:
-				log.Printf("Unknown action: %s", msg.Action)
 			}
-		case websocket.CloseMessage:
-			log.Println("Received close message from client")
-			c.conn.Close()
-			return
-		default:
-			log.Println("Received unknown message from client")
-			c.conn.Close()
-			index := c.Index
-			delete(info.GetAvailableSlots(), index)
-			delete(info.GetNames(), index)
-			delete(clients, index)
-			broadcastMessage(models.BuildServerMessage("leave", fmt.Sprintf("%d", index)))
-			return
+			mutex.Unlock()
+			if seat == nil {
+				break
+			}
+
+			cmd := decide()
+			mutex.Lock()
+			seat.Submit(cmd)
+			mutex.Unlock()
 		}
 	}
 }
 
+// notifyClients is the game listener converting the events at the table to server messages,
+// each sent to the clients allowed to see it
+func notifyClients(event game.Event) {
+	var message []byte
+	switch ev := event.(type) {
+	case game.SeatTaken, game.PlayerReady, game.PlayerArranged:
+		return
+	case game.TokenIssued:
+		sendTo(ev.Index, models.BuildServerMessage("joinConfirm", fmt.Sprintf("%s;%d", ev.Token, teamOf(ev.Index))))
+		// show the house rules to the player taking the seat
+		sendTo(ev.Index, models.BuildServerMessage("rules", models.ConstructRulesServerMessage(engine.Profile())))
+		return
+	case game.Back:
+		broadcastMessage(models.BuildServerMessage("back", models.ConstructPlayerServerMessage(ev.Index, teamOf(ev.Index))))
+		if client, ok := clients[ev.Index]; ok {
+			sendState(client)
+		}
+		return
+	case game.SeatFreed:
+		message = models.BuildServerMessage("leave", models.ConstructPlayerServerMessage(ev.Index, teamOf(ev.Index)))
+	case game.SwapRequested:
+		message = models.BuildServerMessage("swapRequested", models.ConstructPlayerServerMessage(ev.From, teamOf(ev.From), strconv.Itoa(ev.To), strconv.Itoa(teamOf(ev.To))))
+	case game.SeatsSwapped:
+		// the clients follow their players to their new seats
+		moved, other := clients[ev.From], clients[ev.To]
+		delete(clients, ev.From)
+		delete(clients, ev.To)
+		if moved != nil {
+			moved.Index = ev.To
+			clients[ev.To] = moved
+		}
+		if other != nil {
+			other.Index = ev.From
+			clients[ev.From] = other
+		}
+		message = models.BuildServerMessage("seatsSwapped", models.ConstructPlayerServerMessage(ev.From, teamOf(ev.From), strconv.Itoa(ev.To), strconv.Itoa(teamOf(ev.To))))
+	case game.AllJoined:
+		message = models.BuildServerMessage("allJoined", "")
+	case game.Dealt:
+		message = models.BuildServerMessage("startRound", models.ConstructStartRoundServerMessage(ev.Hand, ev.TrumpRank, ev.FinishedIndexes))
+	case game.Revealed:
+		message = models.BuildServerMessage("revealed", models.ConstructPlayerServerMessage(ev.Index, teamOf(ev.Index), ev.Card.CardString()))
+	case game.TributeDue:
+		message = models.BuildServerMessage("tributeDue", models.ConstructTributeServerMessage(ev.From, ev.To, nil, teamOf(ev.From), teamOf(ev.To)))
+	case game.AntiTribute:
+		message = models.BuildServerMessage("antiTribute", strings.Trim(fmt.Sprint(ev.Indexes), "[]"))
+	case game.TributePaid:
+		message = models.BuildServerMessage("tributePaid", models.ConstructTributeServerMessage(ev.From, ev.To, &ev.Card, teamOf(ev.From), teamOf(ev.To)))
+	case game.CardReturned:
+		message = models.BuildServerMessage("cardReturned", models.ConstructTributeServerMessage(ev.From, ev.To, &ev.Card, teamOf(ev.From), teamOf(ev.To)))
+	case game.ArrangingStarted:
+		message = models.BuildServerMessage("arrange", "")
+	case game.Turn:
+		message = models.BuildServerMessage("play", models.ConstructPlayerServerMessage(ev.Index, teamOf(ev.Index)))
+	case game.Played:
+		message = models.BuildServerMessage("lastPlay", models.ConstructLastPlayServerMessage(ev.Index, teamOf(ev.Index), ev.CardsLeft, ev.Cards, ev.Equivalent))
+	case game.PlayChecked:
+		message = models.BuildServerMessage("validPlay", models.ConstructPlayerServerMessage(ev.Index, teamOf(ev.Index)))
+	case game.Passed:
+		message = models.BuildServerMessage("passed", models.ConstructPlayerServerMessage(ev.Index, teamOf(ev.Index)))
+	case game.PlayerFinished:
+		message = models.BuildServerMessage("finished", models.ConstructPlayerServerMessage(ev.Index, teamOf(ev.Index), strconv.Itoa(ev.Place)))
+	case game.RoundOver:
+		message = models.BuildServerMessage("roundOver", models.ConstructRoundOverServerMessage(ev.FinishedIndexes, ev.Team, ev.LevelsUp, ev.Levels))
+	case game.MatchOver:
+		message = models.BuildServerMessage("matchOver", fmt.Sprintf("%d", ev.Team))
+	case game.Away:
+		message = models.BuildServerMessage("away", models.ConstructPlayerServerMessage(ev.Index, teamOf(ev.Index), string(ev.Policy)))
+	default:
+		log.Printf("No server message for event %T", event)
+		return
+	}
+
+	if to := event.Recipient(); to != game.Everybody {
+		sendTo(to, message)
+	} else {
+		broadcastMessage(message)
+	}
+}
+
+// sendState sends the client a snapshot of the table, including its own hand only if it is seated:
+// the index of a client without a seat is stale or 0 and would show the hand of another player
+func sendState(c *Client) {
+	index := game.Everybody
+	if c.isSeated() {
+		index = c.Index
+	}
+	state := engine.State(index)
+	c.write(models.BuildServerMessage("state", models.ConstructStateServerMessage(state)))
+}
+
+// sendError sends a protocol error to the client with the given action, "error" or "invalidPlay"
+func sendError(c *Client, action string, perr *models.ProtocolError) {
+	c.write(models.BuildServerMessage(action, models.ConstructErrorServerMessage(perr)))
+}
+
+// sendTo sends a message to the client seated at index, if it is connected
+func sendTo(index int, message []byte) {
+	if client, ok := clients[index]; ok {
+		client.write(message)
+	}
+}
+
 // broadcastMessage sends a message to all connected clients
 func broadcastMessage(message []byte) {
 	for _, client := range clients {
-		if err := client.conn.WriteMessage(websocket.TextMessage, message); err != nil {
-			log.Printf("Error broadcasting to client: %v", err)
+		client.write(message)
+	}
+}
+
+// seatBots seats n bots at the last seats of the table, searching each play for search if it is set.
+// A seat of a restored table kept for a player is left to the player, and returned in the error.
+func seatBots(n int, search time.Duration) error {
+	var errs []error
+	for index := engine.Table().NumPlayers - n; index < engine.Table().NumPlayers; index++ {
+		name := fmt.Sprintf("bot%d", index)
+		if player := engine.Player(index); player != nil && player.GetName() != name {
+			errs = append(errs, fmt.Errorf("seat %d is kept for %s", index, player.GetName()))
 			continue
 		}
+		var player bot.BotAPI = bot.NewBot(engine.Profile())
+		if search > 0 {
+			player = bot.NewMonteCarlo(engine.Profile(), bot.Budget{Time: search}, time.Now().UnixNano()+int64(index))
+		}
+		seat := bot.NewSeat(engine, index, name, player)
+		if err := seat.Join(); err != nil {
+			errs = append(errs, fmt.Errorf("bot failed to take seat %d: %w", index, err))
+			continue
+		}
+		bots = append(bots, seat)
 	}
+	return errors.Join(errs...)
 }
 
 func main() {
 	// Define command-line flags
-	numPlayers := flag.Int("players", 2, "Number of players in the game")
+	numPlayers := flag.Int("players", 2, "Number of players in the game: 2 for practice, 4 or 6")
+	numTeams := flag.Int("teams", 0, "Number of teams, 2 or 3 at six players, 0 for the usual number for the table size")
 	port := flag.Int("port", 8080, "Port to run the server on")
+	onLeave := flag.String("onLeave", string(models.LeaveWait), "What happens when a player leaves mid-round: wait, bot or forfeit")
+	statePath := flag.String("state", "table.json", "File the table is saved to after every change, empty to not save it")
+	restore := flag.Bool("restore", false, "Continue the unfinished table saved in the state file")
+	rulesPath := flag.String("rules", "", "JSON or YAML file with the house rules, empty for the standard rules")
+	numBots := flag.Int("bots", 0, "Number of seats played by bots, the last seats of the table")
+	search := flag.Duration("search", 0, "Time the bots search each play for, 0 for bots playing by heuristics only")
+	rejoinByName := flag.Bool("rejoinByName", false, "Let players without a seat token take their seat back under the same name, for old clients")
 	flag.Parse()
 
-	// Initialize game info
-	info.SetNumPlayers(*numPlayers)
-	clients = make(map[int]*Client)
+	leavePolicy, err := models.ParseLeavePolicy(*onLeave)
+	if err != nil {
+		log.Fatal(err)
+	}
+	table, err := models.NewTableConfig(*numPlayers, *numTeams)
+	if err != nil {
+		log.Fatal(err)
+	}
+	profile := models.DefaultRuleProfile()
+	if *rulesPath != "" {
+		if profile, err = models.LoadRuleProfile(*rulesPath); err != nil {
+			log.Fatal(err)
+		}
+	}
 
-	// Initialize available slots
-	availableSlots := make(map[int]bool)
-	for i := 0; i < *numPlayers; i++ {
-		availableSlots[i] = true
+	// Initialize the game engine, from the saved table if asked to
+	var store *game.FileStore
+	if *statePath != "" {
+		store = game.NewFileStore(*statePath)
+	}
+	if *restore && store != nil {
+		snapshot, err := store.Load()
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			log.Printf("No table saved in %s, starting a new one", *statePath)
+		case err != nil:
+			log.Fatal(err)
+		case snapshot.Phase == models.PhaseMatchOver:
+			log.Printf("The table saved in %s is finished, starting a new one", *statePath)
+		default:
+			log.Printf("Restoring the %d player table saved in %s, players can take their seats back", snapshot.NumPlayers, *statePath)
+			if engine, err = game.RestoreEngine(snapshot, *rejoinByName); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+	if engine == nil {
+		engine = game.NewEngine(game.Config{
+			Table:        table,
+			Profile:      profile,
+			LeavePolicy:  leavePolicy,
+			RejoinByName: *rejoinByName,
+		})
 	}
-	info.SetAvailableSlots(availableSlots)
+	log.Printf("Table %s: %d players, %d decks, teams %v", engine.Table().Name, engine.Table().NumPlayers, engine.Table().NumDecks, engine.Table().Teams)
+	log.Printf("Rules %s", engine.Profile())
+	engine.AddListener(game.ListenerFunc(notifyClients))
+	if store != nil {
+		engine.AddListener(store.Listener(engine))
+	}
+	if *numBots < 0 || *numBots >= engine.Table().NumPlayers {
+		// a table of bots only would play on forever
+		log.Fatalf("Cannot fill %d seats with bots at a %d player table, leave a seat for a player", *numBots, engine.Table().NumPlayers)
+	}
+	if err := seatBots(*numBots, *search); err != nil {
+		log.Printf("Playing with %d of %d bots: %v", len(bots), *numBots, err)
+	}
+	go playBots()
+	wakeBots()
 
 	// Configure WebSocket route
 	http.HandleFunc("/ws", handleWebSocket)
 
-	rule.SetInfo(info)
-
 	// Start the server
 	serverAddr := fmt.Sprintf(":%d", *port)
 	log.Printf("Server starting on port %d...\n", *port)
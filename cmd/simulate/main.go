@@ -0,0 +1,396 @@
+// Simulate plays complete matches between bots in process, for example: simulate -matches 1000 -bots heuristic,montecarlo
+//
+// It reports the win rate of each pairing of the bots, the rounds per match, the bombs played and the tributes paid,
+// and checks every deal and round result against the rules, exiting with 1 if a command was refused or a check failed.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ChengL-cisco/onlineGuanDan/pkg/bot"
+	"github.com/ChengL-cisco/onlineGuanDan/pkg/game"
+	"github.com/ChengL-cisco/onlineGuanDan/pkg/models"
+)
+
+var (
+	numMatches = flag.Int("matches", 1000, "Number of matches to play, spread over the bot pairings")
+	botKinds   = flag.String("bots", "heuristic", "Comma separated bots to pair against each other: heuristic or montecarlo")
+	numPlayers = flag.Int("players", 4, "Number of players at the table: 2 for practice, 4 or 6")
+	numTeams   = flag.Int("teams", 0, "Number of teams, 2 or 3 at six players, 0 for the usual number for the table size")
+	seed       = flag.Int64("seed", 0, "Seed of the first match, match n is played with seed+n, 0 for a seed from the clock")
+	workers    = flag.Int("workers", runtime.NumCPU(), "Number of matches played at the same time")
+	maxRounds  = flag.Int("maxRounds", 200, "Rounds after which a match is given up as unfinished")
+	iterations = flag.Int("iterations", 10, "Deals the Monte Carlo bots search each play over")
+	search     = flag.Duration("search", 0, "Time the Monte Carlo bots search each play for, 0 for no limit")
+	rulesPath  = flag.String("rules", "", "JSON or YAML file with the house rules, empty for the standard rules")
+	verbose    = flag.Bool("v", false, "Show the engine log")
+)
+
+// pairing is a bot against another. Kinds[0] plays the even teams and Kinds[1] the odd ones,
+// and the other way round in every other match of the pairing.
+type pairing struct {
+	Kinds [2]string
+}
+
+// String names the pairing
+func (p pairing) String() string {
+	return p.Kinds[0] + " vs " + p.Kinds[1]
+}
+
+// match is a match to play
+type match struct {
+	n       int
+	seed    int64
+	pairing int
+	// kinds are the bots of the even teams and of the odd teams
+	kinds [2]string
+	// swapped is true if kinds lists the bots of the pairing the other way round
+	swapped bool
+}
+
+// side returns the bot of the pairing playing team, 0 for Kinds[0] and 1 for Kinds[1]
+func (m match) side(team int) int {
+	if m.swapped {
+		return (team + 1) % 2
+	}
+	return team % 2
+}
+
+// result is what happened in a match
+type result struct {
+	match
+	// winner is the bot of the pairing that won the match, -1 if it was given up
+	winner int
+	rounds int
+	// bombs counts the bombs played by each bot of the pairing
+	bombs [2]int
+	// tributes counts the rounds by tribute: "none", "single", "double" or "anti"
+	tributes map[string]int
+	// paid is the number of rounds in which tribute was paid, and receiverWon how many of them the receivers won
+	paid, receiverWon int
+	// errors lists the refused commands, broken invariants and panics of the match
+	errors []string
+}
+
+// recorder listens to the engine of a match and checks the deals and the round results as it goes
+type recorder struct {
+	engine    *game.Engine
+	result    *result
+	matchOver bool
+	// hands holds the hands dealt this round
+	hands map[int][]models.Card
+	// dues, anti and receiver describe the tribute of the round, receiver being -1 if no tribute was paid
+	dues     int
+	anti     bool
+	receiver int
+}
+
+// OnEvent records the event and checks it against the invariants of the rules
+func (r *recorder) OnEvent(event game.Event) {
+	switch ev := event.(type) {
+	case game.Dealt:
+		r.hands[ev.Index] = ev.Hand
+		if len(r.hands) == r.engine.Table().NumPlayers {
+			r.checkDeal()
+		}
+	case game.TributeDue:
+		r.dues++
+	case game.AntiTribute:
+		r.anti = true
+	case game.TributePaid:
+		r.receiver = r.teamOf(ev.To)
+	case game.Played:
+		combination, err := r.engine.Rule().Classify(ev.Equivalent)
+		if err != nil {
+			r.fail("played %s read as %s, which the rules cannot read: %v", models.CardsString(ev.Cards), models.CardsString(ev.Equivalent), err)
+		} else if combination.Type.IsBomb() {
+			r.result.bombs[r.result.side(r.teamOf(ev.Index))]++
+		}
+	case game.RoundOver:
+		r.checkRound(ev)
+		r.result.rounds++
+		switch {
+		case r.anti:
+			r.result.tributes["anti"]++
+		case r.dues > 1:
+			r.result.tributes["double"]++
+		case r.dues == 1:
+			r.result.tributes["single"]++
+		default:
+			r.result.tributes["none"]++
+		}
+		if r.receiver >= 0 {
+			r.result.paid++
+			if ev.Team == r.receiver {
+				r.result.receiverWon++
+			}
+		}
+		r.hands = make(map[int][]models.Card)
+		r.dues, r.anti, r.receiver = 0, false, -1
+	case game.MatchOver:
+		r.result.winner = r.result.side(ev.Team)
+		r.matchOver = true
+	}
+}
+
+// teamOf returns the team of the seat at index, as the engine arranged the seats
+func (r *recorder) teamOf(index int) int {
+	return r.engine.Info().GetTeamOf(index)
+}
+
+// fail records a broken invariant
+func (r *recorder) fail(format string, args ...interface{}) {
+	r.result.errors = append(r.result.errors, fmt.Sprintf("round %d: ", r.result.rounds+1)+fmt.Sprintf(format, args...))
+}
+
+// checkDeal checks that every player was dealt the hand size of the table from the decks, no card twice,
+// and every card of the decks when the table deals them all
+func (r *recorder) checkDeal() {
+	table := r.engine.Table()
+	count := make(map[models.Card]int)
+	for index, hand := range r.hands {
+		if len(hand) != table.DealtHandSize() {
+			r.fail("player %d was dealt %d cards, the table deals %d", index, len(hand), table.DealtHandSize())
+		}
+		for _, card := range hand {
+			count[card]++
+		}
+	}
+	for _, card := range models.NewDeck(table.NumDecks).GetCards() {
+		if count[card] > 1 || (table.HandSize == 0 && count[card] == 0) {
+			r.fail("%s was dealt %d times", card.CardString(), count[card])
+		}
+		delete(count, card)
+	}
+	for card := range count {
+		r.fail("%s was dealt but is not in the decks", card.CardString())
+	}
+}
+
+// checkRound checks that the finishing order holds every player once, the team of the first player won
+// and went up the levels of the table for the place its last player finished in
+func (r *recorder) checkRound(ev game.RoundOver) {
+	table := r.engine.Table()
+	seen := make(map[int]bool)
+	for _, index := range ev.FinishedIndexes {
+		seen[index] = true
+	}
+	if len(ev.FinishedIndexes) != table.NumPlayers || len(seen) != table.NumPlayers {
+		r.fail("finishing order %v", ev.FinishedIndexes)
+		return
+	}
+	if ev.Team != r.teamOf(ev.FinishedIndexes[0]) {
+		r.fail("team %d won the round finishing %v", ev.Team, ev.FinishedIndexes)
+	}
+	last := 0
+	for place, index := range ev.FinishedIndexes {
+		if r.teamOf(index) == ev.Team {
+			last = place + 1
+		}
+	}
+	if ev.LevelsUp != table.LevelsUp(last) {
+		r.fail("team %d went up %d levels finishing %v, the table goes up %d", ev.Team, ev.LevelsUp, ev.FinishedIndexes, table.LevelsUp(last))
+	}
+}
+
+// newBot returns a bot of the given kind seeded with seed
+func newBot(kind string, profile models.RuleProfile, seed int64) bot.BotAPI {
+	if kind == "montecarlo" {
+		return bot.NewMonteCarlo(profile, bot.Budget{Iterations: *iterations, Time: *search}, seed)
+	}
+	return bot.NewBot(profile)
+}
+
+// play plays a match with the engine and the bots seeded from the seed of the match
+func play(m match, table models.TableConfig, profile models.RuleProfile) (res result) {
+	res = result{match: m, winner: -1, tributes: make(map[string]int)}
+	defer func() {
+		if p := recover(); p != nil {
+			res.errors = append(res.errors, fmt.Sprintf("round %d: panic: %v", res.rounds+1, p))
+		}
+	}()
+
+	engine := game.NewEngine(game.Config{Table: table, Profile: profile, Seed: m.seed})
+	r := &recorder{engine: engine, result: &res, hands: make(map[int][]models.Card), receiver: -1}
+	engine.AddListener(r)
+	var seats []*bot.Seat
+	for index := 0; index < table.NumPlayers; index++ {
+		kind := m.kinds[engine.Info().GetTeamOf(index)%2]
+		seat := bot.NewSeat(engine, index, fmt.Sprintf("%s%d", kind, index), newBot(kind, profile, m.seed*int64(table.NumPlayers)+int64(index)))
+		if err := seat.Join(); err != nil {
+			res.errors = append(res.errors, fmt.Sprintf("seat %d: %v", index, err))
+			return res
+		}
+		seats = append(seats, seat)
+	}
+	refused := bot.RunUntil(engine, seats, func() bool {
+		return r.matchOver || res.rounds >= *maxRounds
+	})
+	for _, err := range refused {
+		res.errors = append(res.errors, err.Error())
+	}
+	return res
+}
+
+// tally sums up the results of the matches of a pairing
+type tally struct {
+	matches, unfinished int
+	// wins counts the matches won by each bot of the pairing
+	wins   [2]int
+	rounds int
+	// bombs counts the bombs played by each bot of the pairing
+	bombs [2]int
+}
+
+func main() {
+	flag.Parse()
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	profile := models.DefaultRuleProfile()
+	if *rulesPath != "" {
+		var err error
+		if profile, err = models.LoadRuleProfile(*rulesPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	table, err := models.NewTableConfig(*numPlayers, *numTeams)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	kinds := strings.Split(*botKinds, ",")
+	for _, kind := range kinds {
+		if kind != "heuristic" && kind != "montecarlo" {
+			fmt.Fprintf(os.Stderr, "unknown bot %q, use heuristic or montecarlo\n", kind)
+			os.Exit(2)
+		}
+	}
+	var pairings []pairing
+	for i := range kinds {
+		for j := i; j < len(kinds); j++ {
+			if i != j || len(kinds) == 1 {
+				pairings = append(pairings, pairing{Kinds: [2]string{kinds[i], kinds[j]}})
+			}
+		}
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	fmt.Printf("Playing %d matches of %s at the %s table with %d workers, seed %d, rules %s\n", *numMatches, strings.Join(func() []string {
+		names := make([]string, len(pairings))
+		for i, p := range pairings {
+			names[i] = p.String()
+		}
+		return names
+	}(), ", "), table.Name, *workers, *seed, profile)
+
+	matches := make(chan match)
+	results := make(chan result)
+	var wg sync.WaitGroup
+	for w := 0; w < max(*workers, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for m := range matches {
+				results <- play(m, table, profile)
+			}
+		}()
+	}
+	go func() {
+		for n := 0; n < *numMatches; n++ {
+			p := n % len(pairings)
+			m := match{n: n, seed: *seed + int64(n), pairing: p, kinds: pairings[p].Kinds}
+			if (n/len(pairings))%2 == 1 {
+				// the bots change sides every other match of the pairing
+				m.kinds[0], m.kinds[1] = m.kinds[1], m.kinds[0]
+				m.swapped = true
+			}
+			matches <- m
+		}
+		close(matches)
+		wg.Wait()
+		close(results)
+	}()
+
+	start := time.Now()
+	tallies := make([]tally, len(pairings))
+	tributes := make(map[string]int)
+	paid, receiverWon, rounds := 0, 0, 0
+	var failures []string
+	for res := range results {
+		t := &tallies[res.pairing]
+		t.matches++
+		t.rounds += res.rounds
+		rounds += res.rounds
+		if res.winner < 0 {
+			t.unfinished++
+		} else {
+			t.wins[res.winner]++
+		}
+		for side, n := range res.bombs {
+			t.bombs[side] += n
+		}
+		for outcome, n := range res.tributes {
+			tributes[outcome] += n
+		}
+		paid += res.paid
+		receiverWon += res.receiverWon
+		for _, e := range res.errors {
+			failures = append(failures, fmt.Sprintf("match %d (seed %d, %s vs %s): %s", res.n, res.seed, res.kinds[0], res.kinds[1], e))
+		}
+	}
+
+	fmt.Printf("Played in %v\n", time.Since(start).Round(time.Millisecond))
+	for p, t := range tallies {
+		if t.matches == 0 {
+			continue
+		}
+		kinds := pairings[p].Kinds
+		fmt.Printf("%s: %d matches, %s won %.1f%%, %s won %.1f%%, %d unfinished, %.1f rounds per match\n", pairings[p], t.matches,
+			kinds[0], percent(t.wins[0], t.matches), kinds[1], percent(t.wins[1], t.matches), t.unfinished, float64(t.rounds)/float64(t.matches))
+		if t.rounds > 0 {
+			fmt.Printf("  bombs per round: %s %.2f, %s %.2f\n", kinds[0], float64(t.bombs[0])/float64(t.rounds), kinds[1], float64(t.bombs[1])/float64(t.rounds))
+		}
+	}
+	if rounds > 0 {
+		fmt.Printf("Tributes: %s\n", strings.Join(func() []string {
+			var parts []string
+			for _, outcome := range []string{"none", "single", "double", "anti"} {
+				parts = append(parts, fmt.Sprintf("%s %.1f%%", outcome, percent(tributes[outcome], rounds)))
+			}
+			return parts
+		}(), ", "))
+		fmt.Printf("The team receiving tribute won %.1f%% of the %d rounds with tribute\n", percent(receiverWon, paid), paid)
+	}
+
+	fmt.Printf("Engine errors: %d\n", len(failures))
+	sort.Strings(failures)
+	for _, failure := range failures {
+		fmt.Println("  " + failure)
+	}
+	if len(failures) > 0 {
+		fmt.Printf("Replay a match with -matches 1 -seed <seed> -bots <even teams>,<odd teams> -v\n")
+		os.Exit(1)
+	}
+}
+
+// percent returns n out of total in percent, 0 if total is 0
+func percent(n int, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(n) / float64(total)
+}
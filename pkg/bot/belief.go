@@ -0,0 +1,287 @@
+package bot
+
+import (
+	"math/rand"
+
+	"github.com/ChengL-cisco/onlineGuanDan/pkg/game"
+	"github.com/ChengL-cisco/onlineGuanDan/pkg/models"
+)
+
+const (
+	// maxResamples is the number of deals drawn at most for a deal weighted by the passes,
+	// the likeliest deal drawn being used if none is accepted
+	maxResamples = 8
+	// passWeight is the likelihood of a pass on an opponent's single, pair or triple the player could have beaten,
+	// players holding back their cards now and then
+	passWeight = 0.25
+	// querySamples is the number of deals probabilities are estimated over
+	querySamples = 200
+)
+
+// Beliefs is what a player infers of the hidden hands of the others from the events it receives in a round:
+// the cards played, the cards moved as tribute and in return, the passes on combinations the players could have
+// beaten and the tributes, which are the highest cards of their payers other than wild cards.
+// It draws deals of the cards the player has not seen that agree with them, and estimates probabilities over such deals.
+type Beliefs struct {
+	profile models.RuleProfile
+	rng     *rand.Rand
+	// played holds the cards played this round
+	played []models.Card
+	// known holds the cards players were seen receiving as tribute or in return and have not played yet
+	known map[int][]models.Card
+	// ceilings holds the tribute of each player who paid one, no other card it was dealt is stronger but wild cards
+	ceilings map[int]models.Card
+	// top is the play the next pass is made on, read with its wild cards replaced, and topIndex its player
+	top      []models.Card
+	topIndex int
+	// passes lists the plays passed on this round
+	passes []pass
+}
+
+// pass is a pass of the player at index on play, made by the player at by
+type pass struct {
+	index int
+	by    int
+	play  []models.Card
+}
+
+// NewBeliefs returns the beliefs of a player at a table played with the given house rules,
+// drawing deals from a random source seeded with seed
+func NewBeliefs(profile models.RuleProfile, seed int64) *Beliefs {
+	b := &Beliefs{
+		profile: profile,
+		rng:     rand.New(rand.NewSource(seed)),
+	}
+	b.Reset()
+	return b
+}
+
+// Reset forgets what was seen of the round
+func (b *Beliefs) Reset() {
+	b.played = nil
+	b.known = make(map[int][]models.Card)
+	b.ceilings = make(map[int]models.Card)
+	b.top = nil
+	b.passes = nil
+}
+
+// Observe updates the beliefs with an event the player received
+func (b *Beliefs) Observe(event game.Event) {
+	switch ev := event.(type) {
+	case game.Dealt:
+		b.Reset()
+	case game.RoundOver:
+		b.Reset()
+	case game.TributePaid:
+		b.ceilings[ev.From] = ev.Card
+		b.give(ev.From, ev.To, ev.Card)
+	case game.CardReturned:
+		b.give(ev.From, ev.To, ev.Card)
+	case game.Played:
+		b.played = append(b.played, ev.Cards...)
+		b.known[ev.Index] = without(b.known[ev.Index], ev.Cards)
+		b.top, b.topIndex = ev.Equivalent, ev.Index
+		if len(b.top) == 0 {
+			b.top = ev.Cards
+		}
+	case game.Passed:
+		if b.top != nil {
+			b.passes = append(b.passes, pass{index: ev.Index, by: b.topIndex, play: b.top})
+		}
+	}
+}
+
+// give notes that card moved from the player at from to the player at to
+func (b *Beliefs) give(from int, to int, card models.Card) {
+	b.known[from] = without(b.known[from], []models.Card{card})
+	b.known[to] = append(b.known[to], card)
+}
+
+// Unseen returns the number of cards of the value of card the player of state holding hand has not seen:
+// neither in its hand, played, nor moved as tribute or in return
+func (b *Beliefs) Unseen(state *models.TableState, hand []models.Card, card models.Card) int {
+	n := 0
+	for _, c := range b.unseen(ruleOf(state, b.profile), state, hand) {
+		if c.SameValue(card) {
+			n++
+		}
+	}
+	return n
+}
+
+// Deal returns the hands of every seat: the hand of the player of state, and for each other player the cards
+// it is known to hold completed with cards it has not seen, none stronger than its tribute but wild cards.
+// Deals are drawn again, up to maxResamples times, with a chance growing with the passes they would explain.
+func (b *Beliefs) Deal(state *models.TableState, hand []models.Card) [][]models.Card {
+	rule := ruleOf(state, b.profile)
+	unseen := b.unseen(rule, state, hand)
+	var best [][]models.Card
+	bestWeight := -1.0
+	for attempt := 0; attempt < maxResamples; attempt++ {
+		hands := b.draw(rule, state, hand, unseen)
+		weight := b.weight(rule, state, hands)
+		if b.rng.Float64() < weight {
+			return hands
+		}
+		if weight > bestWeight {
+			best, bestWeight = hands, weight
+		}
+	}
+	return best
+}
+
+// Probability returns the probability that the hand of the player at index satisfies holds,
+// estimated over querySamples deals weighted by the passes they explain
+func (b *Beliefs) Probability(state *models.TableState, hand []models.Card, index int, holds func(rule *models.Rule, hand []models.Card) bool) float64 {
+	if index < 0 || index >= len(state.Seats) {
+		return 0
+	}
+	rule := ruleOf(state, b.profile)
+	unseen := b.unseen(rule, state, hand)
+	var total, held float64
+	for sample := 0; sample < querySamples; sample++ {
+		hands := b.draw(rule, state, hand, unseen)
+		weight := b.weight(rule, state, hands)
+		total += weight
+		if holds(rule, hands[index]) {
+			held += weight
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return held / total
+}
+
+// BombProbability returns the probability that the player at index holds a bomb
+func (b *Beliefs) BombProbability(state *models.TableState, hand []models.Card, index int) float64 {
+	return b.Probability(state, hand, index, HoldsBomb)
+}
+
+// CardProbability returns the probability that the player at index holds a card of the value of card
+func (b *Beliefs) CardProbability(state *models.TableState, hand []models.Card, index int, card models.Card) float64 {
+	return b.Probability(state, hand, index, func(_ *models.Rule, hand []models.Card) bool {
+		for _, c := range hand {
+			if c.SameValue(card) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// HoldsBomb returns true if a bomb can be played from hand
+func HoldsBomb(rule *models.Rule, hand []models.Card) bool {
+	h := newHandView(rule, hand, maxWilds)
+	for _, o := range h.options(&models.Combination{Type: models.CombinationBomb}) {
+		if o.isBomb() {
+			return true
+		}
+	}
+	return false
+}
+
+// unseen returns the cards of the decks the player of state has not seen, sorted so the deals
+// drawn from them only depend on the seed
+func (b *Beliefs) unseen(rule *models.Rule, state *models.TableState, hand []models.Card) []models.Card {
+	unseen := without(models.NewDeck(models.NumOfDecks(len(state.Seats))).GetCards(), hand)
+	unseen = without(unseen, b.played)
+	for index, cards := range b.known {
+		if index != state.Index {
+			unseen = without(unseen, cards)
+		}
+	}
+	rule.SortCards(unseen, models.SortBySuit)
+	return unseen
+}
+
+// draw deals the cards of unseen at random to the players other than the player of state, after the cards
+// they are known to hold. The players who paid tribute are dealt first, from the cards not stronger than their tribute.
+func (b *Beliefs) draw(rule *models.Rule, state *models.TableState, hand []models.Card, unseen []models.Card) [][]models.Card {
+	pool := append([]models.Card{}, unseen...)
+	b.rng.Shuffle(len(pool), func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
+	hands := make([][]models.Card, len(state.Seats))
+	order := make([]int, 0, len(state.Seats))
+	for index := range state.Seats {
+		if _, ok := b.ceilings[index]; ok {
+			order = append([]int{index}, order...)
+		} else {
+			order = append(order, index)
+		}
+	}
+	for _, index := range order {
+		if index == state.Index {
+			hands[index] = hand
+			continue
+		}
+		left := cardsLeft(state, index)
+		cards := append([]models.Card{}, b.known[index][:min(len(b.known[index]), left)]...)
+		ceiling, capped := b.ceilings[index]
+		rest := pool[:0]
+		for _, card := range pool {
+			if len(cards) < left && (!capped || rule.IsWildCard(card) || !rule.IsRankGreater(card.Rank, ceiling.Rank)) {
+				cards = append(cards, card)
+			} else {
+				rest = append(rest, card)
+			}
+		}
+		hands[index], pool = cards, rest
+	}
+	return hands
+}
+
+// weight returns the likelihood of the passes of the round with hands: passWeight for each pass on
+// an opponent's single, pair or triple the player could have beaten without a wild card or splitting a bomb
+func (b *Beliefs) weight(rule *models.Rule, state *models.TableState, hands [][]models.Card) float64 {
+	weight := 1.0
+	for _, p := range b.passes {
+		if p.index == state.Index || p.index >= len(hands) || p.by >= len(state.Seats) ||
+			state.Seats[p.index].Team == state.Seats[p.by].Team {
+			continue
+		}
+		played, err := rule.Classify(p.play)
+		if err != nil {
+			continue
+		}
+		switch played.Type {
+		case models.CombinationSingle, models.CombinationPair, models.CombinationTriple:
+		default:
+			continue
+		}
+		counts := make(map[models.Rank]int)
+		for _, card := range hands[p.index] {
+			if !rule.IsWildCard(card) {
+				counts[card.Rank]++
+			}
+		}
+		for rank, n := range counts {
+			if n >= played.Size && n < 4 && rule.CompareRank(played.Type, rank, played.Rank) > 0 {
+				weight *= passWeight
+				break
+			}
+		}
+	}
+	return weight
+}
+
+// without returns cards without one card of the same value for each of removed,
+// the very same physical card being taken first
+func without(cards []models.Card, removed []models.Card) []models.Card {
+	rest := append([]models.Card{}, cards...)
+	for _, card := range removed {
+		at := -1
+		for i, c := range rest {
+			if c == card {
+				at = i
+				break
+			}
+			if at < 0 && c.SameValue(card) {
+				at = i
+			}
+		}
+		if at >= 0 {
+			rest = append(rest[:at], rest[at+1:]...)
+		}
+	}
+	return rest
+}
@@ -0,0 +1,573 @@
+package bot
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ChengL-cisco/onlineGuanDan/pkg/models"
+)
+
+const (
+	// dangerCards is the number of cards left at which a player is about to go out,
+	// the bot bombs an opponent holding this many cards or fewer
+	dangerCards = 5
+	// earlyCards is the number of cards above which the round is young and the bot leads its small singles first
+	earlyCards = 10
+	// maxWilds is the most wild cards the bot puts in a single play
+	maxWilds = 2
+)
+
+// Bot is a player following common strategy: it keeps its bombs whole, lets its partner's plays stand,
+// gets rid of its small singles early, saves its wild cards and bombs for when an opponent is about to go out.
+type Bot struct {
+	profile models.RuleProfile
+}
+
+// NewBot returns a bot playing with the given house rules
+func NewBot(profile models.RuleProfile) *Bot {
+	return &Bot{profile: profile}
+}
+
+// Move is what a player does on its turn: it plays Cards read as Declared, or passes if Cards is empty.
+// Equivalent holds the cards the wild cards stand for and is empty for a play without wild cards.
+type Move struct {
+	Cards      []models.Card
+	Equivalent []models.Card
+	Declared   *models.Combination
+}
+
+// IsPass returns true if the move is a pass
+func (m Move) IsPass() bool {
+	return len(m.Cards) == 0
+}
+
+// Play returns the play the bot makes on its turn, or a pass
+func (b *Bot) Play(state *models.TableState, hand []models.Card) Move {
+	if len(hand) == 0 {
+		return Move{}
+	}
+	h := newHandView(ruleOf(state, b.profile), hand, maxWilds)
+	top, leading := topOf(h.rule, state)
+	if leading {
+		return h.lead(h.options(nil))
+	}
+	// a player who went out is no danger, whatever it played
+	danger := cardsLeft(state, state.LastPlayedIndex) <= dangerCards && !isFinished(state, state.LastPlayedIndex)
+	return h.follow(h.options(&top), top, isPartner(state, state.LastPlayedIndex), danger)
+}
+
+// topOf returns the combination on top of the table, and true if the player state was built for leads
+func topOf(rule *models.Rule, state *models.TableState) (models.Combination, bool) {
+	if state.LastPlayedCards == "" || state.LastPlayedIndex == state.Index {
+		return models.Combination{}, true
+	}
+	last, err := models.NewDeckFromString(state.LastPlayedCards)
+	if err != nil {
+		return models.Combination{}, true
+	}
+	top, err := rule.Classify(last.GetCards())
+	if err != nil {
+		return models.Combination{}, true
+	}
+	return top, false
+}
+
+// Tribute returns the strongest card of the hand other than a wild card, as the tribute must be
+func (b *Bot) Tribute(state *models.TableState, hand []models.Card) models.Card {
+	rule := ruleOf(state, b.profile)
+	var highest models.Card
+	for _, card := range hand {
+		if rule.IsWildCard(card) {
+			continue
+		}
+		if highest.Rank == 0 || rule.IsRankGreater(card.Rank, highest.Rank) {
+			highest = card
+		}
+	}
+	return highest
+}
+
+// Return returns the card given back for a tribute: the lowest card of rank 10 or lower that is alone of its rank,
+// or else the lowest card of rank 10 or lower, or the lowest card if there is none
+func (b *Bot) Return(state *models.TableState, hand []models.Card) models.Card {
+	h := newHandView(ruleOf(state, b.profile), hand, maxWilds)
+	var best models.Card
+	bestScore := 0
+	for _, card := range hand {
+		if h.rule.IsWildCard(card) && len(h.wilds) < len(hand) {
+			continue
+		}
+		score := h.value(models.CombinationSingle, card.Rank)
+		if card.Rank > models.Ten {
+			score += 100
+		}
+		if len(h.groups[card.Rank]) > 1 {
+			score += 50
+		}
+		if best.Rank == 0 || score < bestScore {
+			best, bestScore = card, score
+		}
+	}
+	return best
+}
+
+// ruleOf returns the rules of the round of state played with profile, with the teams of the table
+func ruleOf(state *models.TableState, profile models.RuleProfile) *models.Rule {
+	info := &models.Info{}
+	if table, err := models.NewTableConfig(len(state.Seats), len(state.Teams)); err == nil {
+		info.SetTable(table)
+		teams := make([]models.Team, len(state.Teams))
+		for t, team := range state.Teams {
+			teams[t] = models.Team{Name: team.Name, Members: team.Members}
+		}
+		info.SetTeams(teams)
+	}
+	if trump, err := models.ParseRank(state.TrumpRank); err == nil {
+		info.SetTrumpRank(trump)
+	}
+	rule := &models.Rule{}
+	rule.SetInfo(info)
+	rule.SetProfile(profile)
+	return rule
+}
+
+// isPartner returns true if the player at index is on the team of the player state was built for
+func isPartner(state *models.TableState, index int) bool {
+	if index == state.Index || index < 0 || index >= len(state.Seats) || state.Index >= len(state.Seats) {
+		return false
+	}
+	return state.Seats[index].Team == state.Seats[state.Index].Team
+}
+
+// cardsLeft returns the number of cards left to the player at index
+func cardsLeft(state *models.TableState, index int) int {
+	if index < 0 || index >= len(state.Seats) {
+		return 0
+	}
+	return state.Seats[index].CardsLeft
+}
+
+// isFinished returns true if the player at index went out this round
+func isFinished(state *models.TableState, index int) bool {
+	for _, i := range state.FinishedIndexes {
+		if i == index {
+			return true
+		}
+	}
+	return false
+}
+
+// handView is the bot's view of its hand for a round
+type handView struct {
+	rule  *models.Rule
+	trump models.Rank
+	cards []models.Card
+	// wilds are the heart cards of the trump rank
+	wilds []models.Card
+	// groups holds the other cards by rank
+	groups [models.BigJoker + 1][]models.Card
+	// maxWilds is the most wild cards put in a single play
+	maxWilds int
+}
+
+// option is a play the bot considers, read as a combination
+type option struct {
+	cards   []models.Card
+	reading models.Reading
+	// wilds is the number of wild cards played
+	wilds int
+	// splits is the number of ranks of which some cards are played and some kept
+	splits int
+	// breaks is true if the play takes cards from a bomb without playing all of it
+	breaks bool
+	// key orders the plays of the same type by the strength of their key rank
+	key int
+	// low orders the plays by the weakest card they get rid of
+	low int
+}
+
+// newHandView sorts out the wild cards of hand and groups the other cards by rank,
+// the plays considered holding at most maxWilds wild cards
+func newHandView(rule *models.Rule, hand []models.Card, maxWilds int) *handView {
+	h := &handView{
+		rule:     rule,
+		cards:    hand,
+		maxWilds: maxWilds,
+	}
+	for rank := models.Two; rank <= models.Ace; rank++ {
+		if rule.IsWildCard(models.NewCard(models.Heart, rank)) {
+			h.trump = rank
+		}
+	}
+	for _, card := range hand {
+		if rule.IsWildCard(card) {
+			h.wilds = append(h.wilds, card)
+		} else {
+			h.groups[card.Rank] = append(h.groups[card.Rank], card)
+		}
+	}
+	return h
+}
+
+// value orders ranks by strength in combinations of type t, the trump rank keeping its natural place in runs
+func (h *handView) value(t models.CombinationType, rank models.Rank) int {
+	if rank == h.trump && !t.IsRun() {
+		return 2*int(models.Ace) + 1
+	}
+	return 2 * int(rank)
+}
+
+// options returns the plays the bot considers: sets of the same rank, full houses, runs and bombs,
+// completed with at most maxWilds wild cards. If top is not nil, only the plays of its type and the bombs are returned.
+func (h *handView) options(top *models.Combination) []option {
+	var options []option
+	seen := make(map[string]bool)
+	wanted := func(t models.CombinationType) bool {
+		return top == nil || t == top.Type || t.IsBomb() || (t == models.CombinationStraight && top.Type.IsBomb())
+	}
+	add := func(t models.CombinationType, cards []models.Card, equivalent []models.Card) {
+		key := cardsKey(cards)
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		if o, ok := h.option(t, cards, equivalent); ok {
+			options = append(options, o)
+		}
+	}
+	wilds := min(len(h.wilds), h.maxWilds)
+
+	for rank := models.Two; rank <= models.BigJoker; rank++ {
+		group := h.groups[rank]
+		if len(group) == 0 && rank != h.trump {
+			continue
+		}
+		for n, t := range []models.CombinationType{models.CombinationSingle, models.CombinationPair, models.CombinationTriple} {
+			if !wanted(t) {
+				continue
+			}
+			if cards, equivalent, ok := h.fill(rank, n+1, wilds); ok {
+				add(t, cards, equivalent)
+			}
+		}
+		for n := 4; n <= len(group)+wilds; n++ {
+			if cards, equivalent, ok := h.fill(rank, n, wilds); ok {
+				add(models.CombinationBomb, cards, equivalent)
+			}
+		}
+	}
+
+	// full houses of each triple with the cheapest pair, a wild card completing a pair into the triple
+	for triple := models.Two; triple <= models.Ace && wanted(models.CombinationFullHouse); triple++ {
+		use := min(len(h.groups[triple]), 3)
+		need := 3 - use
+		if use < 2 || need > wilds {
+			continue
+		}
+		if pair, ok := h.cheapestPair(triple); ok {
+			cards := append(append([]models.Card{}, h.groups[triple][:use]...), h.groups[pair][:2]...)
+			equivalent := append([]models.Card{}, cards...)
+			for i := 0; i < need; i++ {
+				equivalent = append(equivalent, models.NewCard(models.Heart, triple))
+			}
+			add(models.CombinationFullHouse, append(cards, h.wilds[:need]...), equivalent)
+		}
+	}
+
+	for _, run := range []struct {
+		t      models.CombinationType
+		length int
+		width  int
+	}{{models.CombinationStraight, 5, 1}, {models.CombinationTube, 3, 2}, {models.CombinationPlate, 2, 3}} {
+		if !wanted(run.t) {
+			continue
+		}
+		for _, ranks := range h.runRanks(run.length) {
+			if cards, equivalent, ok := h.fillRun(ranks, run.width, "", wilds); ok {
+				add(run.t, cards, equivalent)
+			}
+		}
+	}
+	straights := h.runRanks(5)
+	for _, suit := range []models.Suit{models.Spade, models.Heart, models.Club, models.Diamond} {
+		for _, ranks := range straights {
+			if cards, equivalent, ok := h.fillRun(ranks, 1, suit, wilds); ok {
+				add(models.CombinationStraightFlush, cards, equivalent)
+			}
+		}
+	}
+	return options
+}
+
+// cheapestPair returns the weakest rank other than except to take a pair from for a full house:
+// a rank held as a pair, or else as a triple, never splitting a bomb
+func (h *handView) cheapestPair(except models.Rank) (models.Rank, bool) {
+	for _, size := range []int{2, 3} {
+		best, found := models.Rank(0), false
+		for rank := models.Two; rank <= models.BigJoker; rank++ {
+			if rank == except || len(h.groups[rank]) != size {
+				continue
+			}
+			if !found || h.value(models.CombinationPair, rank) < h.value(models.CombinationPair, best) {
+				best, found = rank, true
+			}
+		}
+		if found {
+			return best, true
+		}
+	}
+	return 0, false
+}
+
+// fill returns n cards of rank, completed with at most wilds wild cards, and the cards they stand for.
+// Jokers cannot be completed, and only the trump rank can be played with wild cards alone.
+func (h *handView) fill(rank models.Rank, n int, wilds int) ([]models.Card, []models.Card, bool) {
+	group := h.groups[rank]
+	use := min(len(group), n)
+	need := n - use
+	if need > wilds || need > len(h.wilds) || (need > 0 && rank >= models.Joker) || (use == 0 && rank != h.trump) {
+		return nil, nil, false
+	}
+	cards := append([]models.Card{}, group[:use]...)
+	equivalent := append([]models.Card{}, cards...)
+	for i := 0; i < need; i++ {
+		equivalent = append(equivalent, models.NewCard(models.Heart, rank))
+	}
+	return append(cards, h.wilds[:need]...), equivalent, true
+}
+
+// fillRun returns width cards of each of ranks, of the given suit if it is not empty,
+// completed with at most wilds wild cards, and the cards they stand for
+func (h *handView) fillRun(ranks []models.Rank, width int, suit models.Suit, wilds int) ([]models.Card, []models.Card, bool) {
+	need := 0
+	for _, rank := range ranks {
+		use := 0
+		for _, card := range h.groups[rank] {
+			if use < width && (suit == "" || card.Suit == suit) {
+				use++
+			}
+		}
+		need += width - use
+	}
+	if need > wilds || need > len(h.wilds) || need == len(ranks)*width {
+		return nil, nil, false
+	}
+
+	var cards, stands []models.Card
+	for _, rank := range ranks {
+		use := 0
+		for _, card := range h.groups[rank] {
+			if use < width && (suit == "" || card.Suit == suit) {
+				cards = append(cards, card)
+				use++
+			}
+		}
+		for ; use < width; use++ {
+			standSuit := suit
+			if standSuit == "" {
+				standSuit = models.Heart
+			}
+			stands = append(stands, models.NewCard(standSuit, rank))
+		}
+	}
+	equivalent := append(append([]models.Card{}, cards...), stands...)
+	return append(cards, h.wilds[:need]...), equivalent, true
+}
+
+// runRanks returns the ranks of every run of length ranks, with the ace below the two if aces are low
+func (h *handView) runRanks(length int) [][]models.Rank {
+	var runs [][]models.Rank
+	first := models.Two + models.Rank(length) - 1
+	if h.rule.Profile().AceLow {
+		first--
+	}
+	for top := first; top <= models.Ace; top++ {
+		ranks := make([]models.Rank, 0, length)
+		for rank := top - models.Rank(length) + 1; rank <= top; rank++ {
+			if rank < models.Two {
+				ranks = append(ranks, models.Ace)
+			} else {
+				ranks = append(ranks, rank)
+			}
+		}
+		runs = append(runs, ranks)
+	}
+	return runs
+}
+
+// option reads cards, their wild cards standing for the cards of equivalent, as a combination of type t,
+// a straight of a single suit being read as a straight flush
+func (h *handView) option(t models.CombinationType, cards []models.Card, equivalent []models.Card) (option, bool) {
+	if combination, err := h.rule.Classify(equivalent); err == nil {
+		reading := models.Reading{Combination: combination, Equivalent: equivalent}
+		if reading.Type != t && !(t == models.CombinationStraight && reading.Type == models.CombinationStraightFlush) {
+			return option{}, false
+		}
+		o := option{
+			cards:   cards,
+			reading: reading,
+			wilds:   countWild(h, cards),
+			key:     h.value(reading.Type, reading.Rank),
+			low:     2*int(models.BigJoker) + 2,
+		}
+		used := make(map[models.Rank]int)
+		for _, card := range cards {
+			if h.rule.IsWildCard(card) {
+				continue
+			}
+			used[card.Rank]++
+			if v := h.value(reading.Type, card.Rank); v < o.low {
+				o.low = v
+			}
+		}
+		for rank, n := range used {
+			group := len(h.groups[rank])
+			if n < group {
+				o.splits++
+				o.breaks = o.breaks || group >= 4
+			}
+		}
+		return o, true
+	}
+	return option{}, false
+}
+
+// isBomb returns true if the option is read as a bomb
+func (o option) isBomb() bool {
+	return o.reading.Type.IsBomb()
+}
+
+// move returns the move playing the option
+func (o option) move() Move {
+	declared := o.reading.Combination
+	m := Move{Cards: o.cards, Declared: &declared}
+	if o.wilds > 0 {
+		m.Equivalent = o.reading.Equivalent
+	}
+	return m
+}
+
+// less returns true if playing a costs the bot less than playing b: plays other than bombs come first,
+// then plays that keep bombs whole, use fewer wild cards and split fewer ranks, and the weaker bombs.
+// Other plays are then ordered by key rank if byKey is true and by their weakest card otherwise,
+// the play getting rid of more cards first.
+func (h *handView) less(a option, b option, byKey bool) bool {
+	if a.isBomb() != b.isBomb() {
+		return !a.isBomb()
+	}
+	if a.breaks != b.breaks {
+		return !a.breaks
+	}
+	if a.wilds != b.wilds {
+		return a.wilds < b.wilds
+	}
+	if a.isBomb() {
+		if h.rule.Beats(a.reading.Combination, b.reading.Combination) {
+			return true
+		}
+		if h.rule.Beats(b.reading.Combination, a.reading.Combination) {
+			return false
+		}
+	}
+	if a.splits != b.splits {
+		return a.splits < b.splits
+	}
+	if byKey && a.key != b.key {
+		return a.key < b.key
+	}
+	if a.low != b.low {
+		return a.low < b.low
+	}
+	return len(a.cards) > len(b.cards)
+}
+
+// sortOptions sorts options from the cheapest to play
+func (h *handView) sortOptions(options []option, byKey bool) {
+	sort.SliceStable(options, func(i, j int) bool {
+		return h.less(options[i], options[j], byKey)
+	})
+}
+
+// lead returns the play the bot leads a trick with: its whole hand if it can go out, a small single alone
+// of its rank early in the round, and otherwise the cheapest play that keeps its bombs whole
+func (h *handView) lead(options []option) Move {
+	for _, o := range options {
+		if len(o.cards) == len(h.cards) {
+			return o.move()
+		}
+	}
+	if len(h.cards) > earlyCards {
+		var single *option
+		for i, o := range options {
+			rank := o.reading.Rank
+			if o.reading.Type != models.CombinationSingle || o.wilds > 0 || len(h.groups[rank]) != 1 ||
+				o.key >= h.value(models.CombinationSingle, models.Ten) {
+				continue
+			}
+			if single == nil || o.key < single.key {
+				single = &options[i]
+			}
+		}
+		if single != nil {
+			return single.move()
+		}
+	}
+	var whole []option
+	for _, o := range options {
+		if !o.breaks {
+			whole = append(whole, o)
+		}
+	}
+	if len(whole) == 0 {
+		whole = options
+	}
+	h.sortOptions(whole, false)
+	return whole[0].move()
+}
+
+// follow returns the bot's answer to the play top: the play going out if there is one, a pass on its
+// partner's play, and otherwise the cheapest play beating top that keeps its bombs whole. Wild cards and bombs
+// are only spent when the player of top is about to go out, as danger tells.
+func (h *handView) follow(options []option, top models.Combination, partner bool, danger bool) Move {
+	var beating []option
+	for _, o := range options {
+		if h.rule.Beats(top, o.reading.Combination) {
+			if len(o.cards) == len(h.cards) {
+				return o.move()
+			}
+			beating = append(beating, o)
+		}
+	}
+	if partner {
+		return Move{}
+	}
+	h.sortOptions(beating, true)
+	for _, o := range beating {
+		if (o.isBomb() || o.wilds > 0 || o.breaks) && !danger {
+			continue
+		}
+		return o.move()
+	}
+	return Move{}
+}
+
+// countWild returns the number of wild cards among cards
+func countWild(h *handView, cards []models.Card) int {
+	n := 0
+	for _, card := range cards {
+		if h.rule.IsWildCard(card) {
+			n++
+		}
+	}
+	return n
+}
+
+// cardsKey returns a key telling sets of physical cards apart
+func cardsKey(cards []models.Card) string {
+	keys := make([]string, len(cards))
+	for i, card := range cards {
+		keys[i] = string(card.Suit) + string([]byte{byte(card.Rank), byte(card.Deck)})
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, "")
+}
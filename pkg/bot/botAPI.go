@@ -0,0 +1,32 @@
+package bot
+
+import (
+	"github.com/ChengL-cisco/onlineGuanDan/pkg/game"
+	"github.com/ChengL-cisco/onlineGuanDan/pkg/models"
+)
+
+// BotAPI chooses the moves of a player from the table snapshot and the player's own hand
+type BotAPI interface {
+	// Play returns the play the player makes on its turn, or a pass
+	Play(state *models.TableState, hand []models.Card) Move
+
+	// Tribute returns the card the player pays as tribute
+	Tribute(state *models.TableState, hand []models.Card) models.Card
+
+	// Return returns the card the player gives back to the player who paid it tribute
+	Return(state *models.TableState, hand []models.Card) models.Card
+}
+
+// Observer is a bot that remembers what happens at the table
+type Observer interface {
+	// Observe is given every event the player receives, in order
+	Observe(event game.Event)
+}
+
+// Verify at compile time that *Bot and *MonteCarlo implement BotAPI, and *MonteCarlo and *Beliefs implement Observer
+var (
+	_ BotAPI   = (*Bot)(nil)
+	_ BotAPI   = (*MonteCarlo)(nil)
+	_ Observer = (*MonteCarlo)(nil)
+	_ Observer = (*Beliefs)(nil)
+)
@@ -0,0 +1,39 @@
+package bot
+
+import (
+	"github.com/ChengL-cisco/onlineGuanDan/pkg/game"
+	"github.com/ChengL-cisco/onlineGuanDan/pkg/models"
+)
+
+// EventOf returns the game event a server message reports to the player at index, for observers playing
+// over the network, and false if the message reports nothing an observer remembers
+func EventOf(msg *models.ServerMessage, index int) (game.Event, bool) {
+	switch msg.Action {
+	case "startRound":
+		return game.Dealt{Index: index}, true
+	case "tributePaid", "cardReturned":
+		from, to, card, _, err := models.ParseTributeServerMessage(msg.Data)
+		if err != nil || card == nil {
+			return nil, false
+		}
+		if msg.Action == "tributePaid" {
+			return game.TributePaid{From: from, To: to, Card: *card}, true
+		}
+		return game.CardReturned{From: from, To: to, Card: *card}, true
+	case "lastPlay":
+		playerIndex, _, left, cards, equivalent, err := models.ParseLastPlayServerMessage(msg.Data)
+		if err != nil {
+			return nil, false
+		}
+		return game.Played{Index: playerIndex, CardsLeft: left, Cards: cards.GetCards(), Equivalent: equivalent.GetCards()}, true
+	case "passed":
+		playerIndex, _, _, err := models.ParsePlayerServerMessage(msg.Data)
+		if err != nil {
+			return nil, false
+		}
+		return game.Passed{Index: playerIndex}, true
+	case "roundOver":
+		return game.RoundOver{}, true
+	}
+	return nil, false
+}
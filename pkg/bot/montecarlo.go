@@ -0,0 +1,297 @@
+package bot
+
+import (
+	"math"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/ChengL-cisco/onlineGuanDan/pkg/game"
+	"github.com/ChengL-cisco/onlineGuanDan/pkg/models"
+)
+
+const (
+	// maxCandidates is the most moves the Monte Carlo bot plays out on a turn
+	maxCandidates = 8
+	// playoutWilds is the most wild cards the players put in a single play when a round is played out
+	playoutWilds = 1
+	// maxPlayoutTurns bounds the turns of a round played out
+	maxPlayoutTurns = 1000
+	// confidence is the number of standard errors by which a move must beat the heuristic bot's move
+	// on average over the deals searched to be chosen instead, so a short search falls back on the heuristic bot
+	confidence = 1.5
+)
+
+// DefaultBudget is the search budget used when neither bound of a budget is set
+var DefaultBudget = Budget{Time: time.Second}
+
+// Budget bounds the search the Monte Carlo bot does for a move: it stops after Iterations deals
+// or once Time has passed, whichever comes first. A zero bound is no bound.
+type Budget struct {
+	Iterations int
+	Time       time.Duration
+}
+
+// exhausted returns true if the search that started at start and went through iterations deals must stop
+func (b Budget) exhausted(iterations int, start time.Time) bool {
+	return (b.Iterations > 0 && iterations >= b.Iterations) || (b.Time > 0 && time.Since(start) >= b.Time)
+}
+
+// MonteCarlo is a bot searching its plays. It deals the cards it has not seen to the other players
+// as its beliefs about their hands tell, plays each candidate move out
+// to the end of the round with every player following the heuristic bot, and picks the move with the best
+// average result for its team, keeping the heuristic bot's move unless another clearly does better.
+// Tributes and returned cards are chosen by the heuristic bot.
+type MonteCarlo struct {
+	profile   models.RuleProfile
+	budget    Budget
+	heuristic *Bot
+	beliefs   *Beliefs
+}
+
+// NewMonteCarlo returns a Monte Carlo bot playing with the given house rules within budget,
+// drawing its deals from a random source seeded with seed
+func NewMonteCarlo(profile models.RuleProfile, budget Budget, seed int64) *MonteCarlo {
+	if budget.Iterations <= 0 && budget.Time <= 0 {
+		budget = DefaultBudget
+	}
+	return &MonteCarlo{
+		profile:   profile,
+		budget:    budget,
+		heuristic: NewBot(profile),
+		beliefs:   NewBeliefs(profile, seed),
+	}
+}
+
+// Observe updates the bot's beliefs about the hands of the other players
+func (m *MonteCarlo) Observe(event game.Event) {
+	m.beliefs.Observe(event)
+}
+
+// Play returns the candidate move with the best average result over the deals searched within the budget,
+// if it beats the heuristic bot's move by confidence standard errors, and the heuristic bot's move otherwise.
+// Every candidate is played out on the same deals, by a pool of one worker per CPU.
+func (m *MonteCarlo) Play(state *models.TableState, hand []models.Card) Move {
+	rule := ruleOf(state, m.profile)
+	candidates := m.candidates(rule, state, hand)
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+
+	type job struct {
+		candidate int
+		hands     [][]models.Card
+	}
+	type result struct {
+		candidate, levels int
+	}
+	jobs := make(chan job, len(candidates))
+	results := make(chan result, len(candidates))
+	var wg sync.WaitGroup
+	for w := 0; w < min(runtime.NumCPU(), len(candidates)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				results <- result{j.candidate, playout(rule, m.profile, state, j.hands, candidates[j.candidate])}
+			}
+		}()
+	}
+	defer func() {
+		close(jobs)
+		wg.Wait()
+	}()
+
+	// sum and squares add up the difference between the result of each candidate and the heuristic bot's move
+	sum := make([]float64, len(candidates))
+	squares := make([]float64, len(candidates))
+	levels := make([]int, len(candidates))
+	iterations := 0
+	for start := time.Now(); !m.budget.exhausted(iterations, start); iterations++ {
+		hands := m.beliefs.Deal(state, hand)
+		for c := range candidates {
+			jobs <- job{c, hands}
+		}
+		for range candidates {
+			r := <-results
+			levels[r.candidate] = r.levels
+		}
+		for c := range candidates {
+			d := float64(levels[c] - levels[0])
+			sum[c] += d
+			squares[c] += d * d
+		}
+	}
+
+	best, bestMean := 0, 0.0
+	for c := 1; c < len(candidates) && iterations > 1; c++ {
+		n := float64(iterations)
+		mean := sum[c] / n
+		variance := (squares[c] - n*mean*mean) / (n - 1)
+		if mean > bestMean && mean > confidence*math.Sqrt(math.Max(variance, 0)/n) {
+			best, bestMean = c, mean
+		}
+	}
+	return candidates[best]
+}
+
+// Tribute returns the card the heuristic bot pays as tribute
+func (m *MonteCarlo) Tribute(state *models.TableState, hand []models.Card) models.Card {
+	return m.heuristic.Tribute(state, hand)
+}
+
+// Return returns the card the heuristic bot gives back
+func (m *MonteCarlo) Return(state *models.TableState, hand []models.Card) models.Card {
+	return m.heuristic.Return(state, hand)
+}
+
+// candidates returns the moves searched: the heuristic bot's move first, which wins ties, a pass when following,
+// then the cheapest play of each type and the next cheapest plays, up to maxCandidates moves
+func (m *MonteCarlo) candidates(rule *models.Rule, state *models.TableState, hand []models.Card) []Move {
+	moves := []Move{m.heuristic.Play(state, hand)}
+	seen := map[string]bool{cardsKey(moves[0].Cards): true}
+	add := func(move Move) {
+		if key := cardsKey(move.Cards); !seen[key] && len(moves) < maxCandidates {
+			seen[key] = true
+			moves = append(moves, move)
+		}
+	}
+
+	h := newHandView(rule, hand, maxWilds)
+	top, leading := topOf(rule, state)
+	var options []option
+	if leading {
+		options = h.options(nil)
+	} else {
+		add(Move{})
+		for _, o := range h.options(&top) {
+			if rule.Beats(top, o.reading.Combination) {
+				options = append(options, o)
+			}
+		}
+	}
+	h.sortOptions(options, !leading)
+	types := make(map[models.CombinationType]bool)
+	for _, o := range options {
+		if !types[o.reading.Type] {
+			types[o.reading.Type] = true
+			add(o.move())
+		}
+	}
+	for _, o := range options {
+		add(o.move())
+	}
+	return moves
+}
+
+// playout plays the round of state out with the dealt hands, the bot making move and every player
+// then following the heuristic bot, and returns the levels the bot's team goes up, negative if the other team wins
+func playout(rule *models.Rule, profile models.RuleProfile, state *models.TableState, dealt [][]models.Card, move Move) int {
+	n := len(state.Seats)
+	hands := make([][]models.Card, n)
+	for index := range dealt {
+		hands[index] = append([]models.Card{}, dealt[index]...)
+	}
+	finished := append([]int{}, state.FinishedIndexes...)
+	isFinished := func(index int) bool {
+		for _, i := range finished {
+			if i == index {
+				return true
+			}
+		}
+		return false
+	}
+	next := func(index int) int {
+		i := (index + 1) % n
+		for i != index && isFinished(i) {
+			i = (i + 1) % n
+		}
+		return i
+	}
+	team := func(index int) int {
+		return state.Seats[index].Team
+	}
+
+	top, leading := topOf(rule, state)
+	topIndex := state.LastPlayedIndex
+	current := state.Index
+	for turn := 0; len(finished) < n-1 && turn < maxPlayoutTurns; turn++ {
+		if turn > 0 {
+			h := newHandView(rule, hands[current], playoutWilds)
+			if leading {
+				move = h.lead(h.options(nil))
+			} else {
+				danger := len(hands[topIndex]) <= dangerCards && !isFinished(topIndex)
+				move = h.follow(h.options(&top), top, team(topIndex) == team(current), danger)
+			}
+		}
+		var played models.Combination
+		if !move.IsPass() {
+			equivalent := move.Equivalent
+			if len(equivalent) == 0 {
+				equivalent = move.Cards
+			}
+			var err error
+			if played, err = rule.Classify(equivalent); err != nil {
+				move = Move{}
+			}
+		}
+		if move.IsPass() && leading {
+			// a leader cannot pass, it plays its first card
+			move = Move{Cards: hands[current][:1]}
+			played, _ = rule.Classify(move.Cards)
+		}
+
+		if move.IsPass() {
+			following := next(current)
+			if wonBy(n, current, following, topIndex) {
+				// everybody passed on the last play, its player or with jie feng its next partner still playing leads
+				leading = true
+				following = topIndex
+				if isFinished(topIndex) {
+					following = next(current)
+					for i := (topIndex + 1) % n; profile.JieFeng && i != topIndex; i = (i + 1) % n {
+						if team(i) == team(topIndex) && !isFinished(i) {
+							following = i
+							break
+						}
+					}
+				}
+			}
+			current = following
+			continue
+		}
+
+		hands[current] = without(hands[current], move.Cards)
+		if len(hands[current]) == 0 {
+			finished = append(finished, current)
+		}
+		top, topIndex, leading = played, current, false
+		current = next(current)
+	}
+
+	order := append([]int{}, finished...)
+	for index := 0; index < n && len(order) < n; index++ {
+		if !isFinished(index) {
+			order = append(order, index)
+		}
+	}
+	winner, levels := rule.LevelUp(order)
+	if winner == team(state.Index) {
+		return levels
+	}
+	return -levels
+}
+
+// wonBy returns true if the turn moving from index to next around a table of n players goes past last,
+// the player who made the last play, which means every other player still in the round has passed
+func wonBy(n int, index int, next int, last int) bool {
+	for i := (index + 1) % n; ; i = (i + 1) % n {
+		if i == last {
+			return true
+		}
+		if i == next {
+			return false
+		}
+	}
+}
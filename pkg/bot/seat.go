@@ -0,0 +1,224 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/ChengL-cisco/onlineGuanDan/pkg/game"
+	"github.com/ChengL-cisco/onlineGuanDan/pkg/models"
+)
+
+// Seat plays a seat of an engine in-process with a bot.
+// It listens to the engine for what the seat is asked to do, and as a listener must not call Handle,
+// Next gives the bot's answer once the engine is done with the command it was handling.
+// Decide and Submit split Next so the bot can think while the engine handles the commands of other players.
+type Seat struct {
+	index  int
+	name   string
+	bot    BotAPI
+	engine *game.Engine
+	// the engine waits for the seat to get ready, pay tribute, return a card, start the round or play
+	ready, tribute, ret, start, turn bool
+	// observed holds the events for a bot observing the table, passed on when it next decides
+	observed []game.Event
+}
+
+// NewSeat returns a seat at index of engine played by bot under name, listening to the engine
+func NewSeat(engine *game.Engine, index int, name string, bot BotAPI) *Seat {
+	s := &Seat{
+		index:  index,
+		name:   name,
+		bot:    bot,
+		engine: engine,
+	}
+	engine.AddListener(s)
+	return s
+}
+
+// Index returns the index of the seat, which changes when players swap seats
+func (s *Seat) Index() int {
+	return s.index
+}
+
+// Join takes the seat at the table, or takes back the seat kept under the same name after the table was restored
+func (s *Seat) Join() error {
+	join := game.Join{Index: s.index, Name: s.name}
+	if player := s.engine.Player(s.index); player != nil && player.GetName() == s.name {
+		// the seat plays in-process, it may read the token of the seat kept for it
+		join.Token = player.GetToken()
+	}
+	_, err := s.engine.Handle(join)
+	return err
+}
+
+// OnEvent notes what the engine waits for from the seat, and keeps the events the seat receives
+// for a bot that observes the table
+func (s *Seat) OnEvent(event game.Event) {
+	if _, ok := s.bot.(Observer); ok {
+		if to := event.Recipient(); to == game.Everybody || to == s.index {
+			s.observed = append(s.observed, event)
+		}
+	}
+	switch ev := event.(type) {
+	case game.AllJoined:
+		s.ready = true
+	case game.SeatsSwapped:
+		if ev.From == s.index {
+			s.index = ev.To
+		} else if ev.To == s.index {
+			s.index = ev.From
+		}
+	case game.TributeDue:
+		s.tribute = s.tribute || ev.From == s.index
+	case game.TributePaid:
+		s.ret = s.ret || ev.To == s.index
+	case game.ArrangingStarted:
+		s.start = true
+	case game.Turn:
+		s.turn = ev.Index == s.index
+	case game.RoundOver:
+		s.turn = false
+	case game.Back:
+		if ev.Index == s.index {
+			s.resume()
+		}
+	}
+}
+
+// resume works out what the engine waits for from the seat taken back in the middle of a match
+func (s *Seat) resume() {
+	switch s.engine.Phase() {
+	case models.PhaseLobby, models.PhaseRoundOver, models.PhaseMatchOver:
+		s.ready = true
+	case models.PhaseTribute:
+		// the engine refuses the tribute or the return the seat does not owe
+		s.tribute, s.ret = true, true
+	case models.PhaseArranging:
+		s.start = true
+	case models.PhasePlaying:
+		s.turn = s.engine.Info().GetCurrentPlayerIndex() == s.index
+	}
+}
+
+// Next returns the command the bot answers the engine with, and false if the engine waits for nothing from the seat
+func (s *Seat) Next() (game.Command, bool) {
+	decide, ok := s.Decide()
+	if !ok {
+		return nil, false
+	}
+	return decide(), true
+}
+
+// Decide returns what the engine waits for from the seat as a function returning the bot's answer,
+// and false if the engine waits for nothing. It must be called while no command is handled,
+// the function returned only uses a copy of the table and the bot, and can be called while the engine
+// handles other commands. The bot is only used by the function, which must be called before the seat decides again.
+func (s *Seat) Decide() (func() game.Command, bool) {
+	hand := s.engine.Hand(s.index)
+	if hand == nil {
+		return nil, false
+	}
+	index := s.index
+	cards := append([]models.Card{}, hand.GetCards()...)
+	observed := s.observed
+	s.observed = nil
+	// decide passes the events observed since the last decision on to the bot before it answers
+	decide := func(answer func(state *models.TableState) game.Command) func() game.Command {
+		state := s.engine.State(index)
+		return func() game.Command {
+			if observer, ok := s.bot.(Observer); ok {
+				for _, event := range observed {
+					observer.Observe(event)
+				}
+			}
+			return answer(state)
+		}
+	}
+	switch {
+	case s.ready:
+		s.ready = false
+		return decide(func(*models.TableState) game.Command { return game.Ready{Index: index} }), true
+	case s.tribute:
+		s.tribute = false
+		return decide(func(state *models.TableState) game.Command {
+			return game.Tribute{Index: index, Card: s.bot.Tribute(state, cards)}
+		}), true
+	case s.ret:
+		s.ret = false
+		return decide(func(state *models.TableState) game.Command {
+			return game.Return{Index: index, Card: s.bot.Return(state, cards)}
+		}), true
+	case s.start:
+		s.start = false
+		return decide(func(*models.TableState) game.Command { return game.Start{Index: index} }), true
+	case s.turn:
+		s.turn = false
+		return decide(func(state *models.TableState) game.Command {
+			move := s.bot.Play(state, cards)
+			if move.IsPass() {
+				return game.Pass{Index: index}
+			}
+			return game.Play{Index: index, Cards: move.Cards, Equivalent: move.Equivalent, Declared: move.Declared}
+		}), true
+	}
+	s.observed = observed
+	return nil, false
+}
+
+// Submit hands the engine the command the bot decided on. A refused command is logged and returned,
+// and a refused play is replaced by a pass or, when the seat leads, by its lowest card, so the table never waits for a bot.
+func (s *Seat) Submit(cmd game.Command) error {
+	if _, err := s.engine.Handle(cmd); err != nil {
+		log.Printf("Bot %s at %d: %T refused: %v", s.name, s.index, cmd, err)
+		if _, isPlay := cmd.(game.Play); isPlay {
+			s.fallBack()
+		}
+		return fmt.Errorf("bot %s at %d: %T refused: %w", s.name, s.index, cmd, err)
+	}
+	return nil
+}
+
+// Run lets the seats answer the engine until the engine waits for none of them, submitting their answers
+func Run(engine *game.Engine, seats []*Seat) {
+	RunUntil(engine, seats, func() bool { return false })
+}
+
+// RunUntil is Run stopping as soon as stop returns true, as a table of bots only never stops on its own.
+// It returns the errors of the commands the engine refused.
+func RunUntil(engine *game.Engine, seats []*Seat, stop func() bool) []error {
+	var refused []error
+	for busy := true; busy && !stop(); {
+		busy = false
+		for _, seat := range seats {
+			if stop() {
+				break
+			}
+			cmd, ok := seat.Next()
+			if !ok {
+				continue
+			}
+			busy = true
+			if err := seat.Submit(cmd); err != nil {
+				refused = append(refused, err)
+			}
+		}
+	}
+	return refused
+}
+
+// fallBack passes the seat's turn after a refused play, or plays its lowest card if it leads
+func (s *Seat) fallBack() {
+	if _, err := s.engine.Handle(game.Pass{Index: s.index}); err == nil {
+		return
+	}
+	cards := s.engine.Hand(s.index).GetCards()
+	lowest := cards[0]
+	for _, card := range cards[1:] {
+		if s.engine.Rule().IsRankGreater(lowest.Rank, card.Rank) {
+			lowest = card
+		}
+	}
+	if _, err := s.engine.Handle(game.Play{Index: s.index, Cards: []models.Card{lowest}}); err != nil {
+		log.Printf("Bot %s at %d cannot play %s: %v", s.name, s.index, lowest.CardString(), err)
+	}
+}
@@ -0,0 +1,103 @@
+package game
+
+import "github.com/ChengL-cisco/onlineGuanDan/pkg/models"
+
+// Command is a request from a player to the engine, handled by Engine.Handle
+type Command interface {
+	// Player returns the index of the player issuing the command
+	Player() int
+}
+
+// Join takes the seat at Index under Name, or takes back the seat kept for an away player
+// with the seat's Token, or with the same name if Token is empty and the engine lets players rejoin by name
+type Join struct {
+	Index int
+	Name  string
+	Token string
+}
+
+// Ready marks the player as ready to start the next round, cards are dealt once everybody is ready
+type Ready struct {
+	Index int
+}
+
+// Start marks the player as done arranging cards, the round starts once everybody is
+type Start struct {
+	Index int
+}
+
+// Play plays Cards read as Equivalent, which may be empty if no wild card stands in for another card.
+// Declared, if set, is the combination the player means the play to be, the engine finds the equivalent
+// if none is given. With DryRun the play is only checked.
+type Play struct {
+	Index      int
+	Cards      []models.Card
+	Equivalent []models.Card
+	Declared   *models.Combination
+	DryRun     bool
+}
+
+// Pass passes the player's turn
+type Pass struct {
+	Index int
+}
+
+// Tribute pays Card as tribute to the winner of the last round
+type Tribute struct {
+	Index int
+	Card  models.Card
+}
+
+// Return gives Card back to the player who paid tribute
+type Return struct {
+	Index int
+	Card  models.Card
+}
+
+// ChooseTeam moves the player to a free seat of Team, before the match starts
+type ChooseTeam struct {
+	Index int
+	Team  int
+}
+
+// SwapSeats moves the player to the seat at With before the match starts.
+// If the seat is taken, its player has to ask for the same swap before the players change seats.
+type SwapSeats struct {
+	Index int
+	With  int
+}
+
+// Leave removes the player from the seat, the table's leave policy applies during a round
+type Leave struct {
+	Index int
+}
+
+// Player returns the index of the player issuing the command
+func (c Join) Player() int { return c.Index }
+
+// Player returns the index of the player issuing the command
+func (c Ready) Player() int { return c.Index }
+
+// Player returns the index of the player issuing the command
+func (c Start) Player() int { return c.Index }
+
+// Player returns the index of the player issuing the command
+func (c Play) Player() int { return c.Index }
+
+// Player returns the index of the player issuing the command
+func (c Pass) Player() int { return c.Index }
+
+// Player returns the index of the player issuing the command
+func (c Tribute) Player() int { return c.Index }
+
+// Player returns the index of the player issuing the command
+func (c Return) Player() int { return c.Index }
+
+// Player returns the index of the player issuing the command
+func (c ChooseTeam) Player() int { return c.Index }
+
+// Player returns the index of the player issuing the command
+func (c SwapSeats) Player() int { return c.Index }
+
+// Player returns the index of the player issuing the command
+func (c Leave) Player() int { return c.Index }
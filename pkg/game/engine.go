@@ -0,0 +1,740 @@
+package game
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	mathrand "math/rand"
+	"sort"
+	"time"
+
+	"github.com/ChengL-cisco/onlineGuanDan/pkg/models"
+)
+
+// Config holds the settings of a table
+type Config struct {
+	// Table is the configuration of the table, which must be valid
+	Table models.TableConfig
+	// Profile holds the house rules, the zero profile plays the default rules
+	Profile models.RuleProfile
+	// LeavePolicy decides what happens when a seated player leaves mid-round
+	LeavePolicy models.LeavePolicy
+	// Seed seeds the engine's random choices and shuffles, the same seed dealing the same cards, 0 seeds from the clock
+	Seed int64
+	// RejoinByName lets a player without a seat token take an away seat back under the same name,
+	// for clients that do not keep their token. Anyone knowing the name can then take the seat.
+	RejoinByName bool
+}
+
+// Engine runs the game at one table as a state machine going through the phases
+// Lobby, Dealing, Tribute, Arranging, Playing, RoundOver and MatchOver.
+// It owns the game info, the rules and the players with their hands, takes typed commands and reports events.
+// Engine is not safe for concurrent use.
+type Engine struct {
+	info *models.Info
+	rule *models.Rule
+	// players maps each seat taken to its player, including the seats kept for away players
+	players map[int]*models.Player
+	// swapRequests maps each player asking to swap seats to the seat asked for
+	swapRequests map[int]int
+	// tribute tracks the tribute of the current round, nil when no tribute is due
+	tribute *tributeRound
+	// firstRound is true until the first round of the match is dealt
+	firstRound bool
+	// rejoinByName lets an away seat be taken back under the same name without its token
+	rejoinByName bool
+	rng          *mathrand.Rand
+	// events collects the events of the command being handled
+	events []Event
+	// listeners are notified of the events of every command handled
+	listeners []GameListener
+}
+
+// NewEngine creates an engine for a table with the given config, waiting for players to join
+func NewEngine(config Config) *Engine {
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	e := &Engine{
+		info:         &models.Info{},
+		rule:         &models.Rule{},
+		players:      make(map[int]*models.Player),
+		swapRequests: make(map[int]int),
+		firstRound:   true,
+		rejoinByName: config.RejoinByName,
+		rng:          mathrand.New(mathrand.NewSource(seed)),
+	}
+	e.info.SetTable(config.Table)
+	e.info.SetLeavePolicy(config.LeavePolicy)
+	availableSlots := make(map[int]bool)
+	for i := 0; i < config.Table.NumPlayers; i++ {
+		availableSlots[i] = true
+	}
+	e.info.SetAvailableSlots(availableSlots)
+	e.rule.SetInfo(e.info)
+	e.rule.SetProfile(config.Profile)
+	return e
+}
+
+// Info returns the game info of the table
+func (e *Engine) Info() models.InfoAPI {
+	return e.info
+}
+
+// Table returns the configuration of the table
+func (e *Engine) Table() models.TableConfig {
+	return e.info.GetTable()
+}
+
+// Profile returns the house rules played at the table
+func (e *Engine) Profile() models.RuleProfile {
+	return e.rule.Profile()
+}
+
+// Rule returns the rules used at the table
+func (e *Engine) Rule() *models.Rule {
+	return e.rule
+}
+
+// Phase returns the current phase of the game
+func (e *Engine) Phase() models.Phase {
+	return e.info.GetPhase()
+}
+
+// Player returns the player seated at index, or nil if the seat is available
+func (e *Engine) Player(index int) *models.Player {
+	return e.players[index]
+}
+
+// Hand returns the hand of the player at index, or nil if no player sits at index
+func (e *Engine) Hand(index int) *models.Deck {
+	if player, ok := e.players[index]; ok {
+		return player.GetHand()
+	}
+	return nil
+}
+
+// AvailableSlots returns the sorted indexes of the seats a player can join,
+// including the seats kept for away players, which can only be taken back with their token
+func (e *Engine) AvailableSlots() []int {
+	slots := make([]int, 0, e.info.GetNumPlayers())
+	for index := range e.info.GetAvailableSlots() {
+		slots = append(slots, index)
+	}
+	for index := 0; index < e.info.GetNumPlayers(); index++ {
+		if e.isAway(index) {
+			slots = append(slots, index)
+		}
+	}
+	sort.Ints(slots)
+	return slots
+}
+
+// State returns a snapshot of the table for the player at index, with no hand for Everybody
+func (e *Engine) State(index int) *models.TableState {
+	return models.NewTableState(e.info, e.players, index)
+}
+
+// Handle applies a command, notifies the listeners and returns the resulting events in order.
+// It returns a *models.ProtocolError and no events if the command is refused.
+// When the leave policy is LeaveBot, the engine also acts for away players before returning.
+func (e *Engine) Handle(cmd Command) ([]Event, error) {
+	e.events = nil
+
+	var err *models.ProtocolError
+	switch cmd := cmd.(type) {
+	case Join:
+		err = e.join(cmd)
+	case Ready:
+		err = e.ready(cmd)
+	case Start:
+		err = e.start(cmd)
+	case Play:
+		err = e.play(cmd)
+	case Pass:
+		err = e.pass(cmd)
+	case Tribute:
+		err = e.payTribute(cmd)
+	case Return:
+		err = e.returnCard(cmd)
+	case ChooseTeam:
+		err = e.chooseTeam(cmd)
+	case SwapSeats:
+		err = e.swapSeats(cmd)
+	case Leave:
+		err = e.leave(cmd)
+	default:
+		err = models.NewProtocolError(models.CodeMalformed, "unknown command %T", cmd)
+	}
+	if err != nil {
+		e.events = nil
+		return nil, err
+	}
+
+	e.playAwayTurns()
+	events := e.events
+	e.events = nil
+	e.notify(events)
+	return events, nil
+}
+
+// emit records an event of the command being handled
+func (e *Engine) emit(event Event) {
+	e.events = append(e.events, event)
+}
+
+// isSeated returns true if a player who is not away sits at index
+func (e *Engine) isSeated(index int) bool {
+	player, ok := e.players[index]
+	return ok && !player.IsAway()
+}
+
+// isAway returns true if the seat at index is kept for a player who left mid-round
+func (e *Engine) isAway(index int) bool {
+	player, ok := e.players[index]
+	return ok && player.IsAway()
+}
+
+// checkSeated returns an error if no player who is not away sits at index
+func (e *Engine) checkSeated(index int) *models.ProtocolError {
+	if !e.isSeated(index) {
+		return models.NewProtocolError(models.CodeNotSeated, "no player seated at %d", index)
+	}
+	return nil
+}
+
+// numSeated returns the number of seats taken, including the seats kept for away players
+func (e *Engine) numSeated() int {
+	return len(e.players)
+}
+
+// join seats a player, or gives an away player their seat back if the seat token matches,
+// or without a token the name when the engine lets players rejoin by name
+func (e *Engine) join(cmd Join) *models.ProtocolError {
+	if player, ok := e.players[cmd.Index]; ok && player.IsAway() {
+		byName := e.rejoinByName && cmd.Token == "" && cmd.Name == player.GetName()
+		if cmd.Token != player.GetToken() && !byName {
+			return models.NewProtocolError(models.CodeSeatTaken, "seat %d is kept for its player", cmd.Index)
+		}
+		// the player is coming back to the seat kept for them
+		log.Printf("Player %d is back", cmd.Index)
+		player.ComeBack()
+		e.emit(TokenIssued{Index: cmd.Index, Token: player.GetToken()})
+		e.emit(Back{Index: cmd.Index})
+		return nil
+	}
+
+	if !e.info.TakeSeat(cmd.Index, cmd.Name) {
+		return models.NewProtocolError(models.CodeSeatTaken, "seat %d is not available", cmd.Index)
+	}
+	player := models.NewPlayer(cmd.Index, cmd.Name, e.info)
+	player.SetToken(newToken())
+	e.players[cmd.Index] = player
+	e.emit(SeatTaken{Index: cmd.Index, Name: cmd.Name})
+	e.emit(TokenIssued{Index: cmd.Index, Token: player.GetToken()})
+
+	if e.numSeated() == e.info.GetNumPlayers() {
+		log.Printf("Everybody joined, getting ready...")
+		e.emit(AllJoined{})
+	}
+	return nil
+}
+
+// ready marks the player as ready and deals the cards once everybody is
+func (e *Engine) ready(cmd Ready) *models.ProtocolError {
+	if err := e.checkSeated(cmd.Index); err != nil {
+		return err
+	}
+	switch e.info.GetPhase() {
+	case models.PhaseLobby, models.PhaseRoundOver, models.PhaseMatchOver:
+	default:
+		return models.NewProtocolError(models.CodeWrongPhase, "cannot get ready in the %s phase", e.info.GetPhase())
+	}
+
+	allReady := e.players[cmd.Index].ReadyToStart()
+	e.emit(PlayerReady{Index: cmd.Index})
+	// if everybody is ready, send out the cards
+	if allReady {
+		log.Printf("Everybody is ready, starting the game...")
+		e.deal()
+	}
+	return nil
+}
+
+// deal starts a new round: it deals the cards and moves on to the tribute or straight to arranging
+func (e *Engine) deal() {
+	// the first round of a match starts from level Two with no tribute and a leader chosen as the rule profile says
+	newMatch := e.firstRound || e.info.GetPhase() == models.PhaseMatchOver
+	e.firstRound = false
+	e.info.SetPhase(models.PhaseDealing)
+	e.info.SetIsRoundInSession(true)
+	if newMatch {
+		teams := e.info.GetTeams()
+		for t := range teams {
+			teams[t].Level = models.Two
+			teams[t].AAttempts = 0
+		}
+		e.info.SetTeams(teams)
+		if e.rule.Profile().FirstLead == models.FirstLeadRandom {
+			e.info.SetCurrentPlayerIndex(e.rng.Intn(e.info.GetNumPlayers()))
+		}
+		e.info.SetTrumpRank(models.Two)
+		e.info.ResetFinishedIndexes()
+	}
+
+	table := e.info.GetTable()
+	deck := models.NewDeckWith(table.NumDecks, e.rng)
+	if table.HandSize > 0 {
+		// a reduced deck, only the cards dealt take part in the round
+		reduced := &models.Deck{}
+		for _, card := range deck.DrawN(table.HandSize * table.NumPlayers) {
+			reduced.Add(card)
+		}
+		deck = reduced
+	}
+
+	lastOrder := e.info.GetFinishedIndexes()
+	var decks []*models.Deck
+	if newMatch && e.rule.Profile().FirstLead == models.FirstLeadReveal {
+		// a card is turned face up during the deal and whoever receives its twin leads the first round
+		revealed := deck.GetCards()[e.rng.Intn(deck.Count())]
+		var leader int
+		decks, leader = deck.SplitRevealing(e.info.GetNumPlayers(), revealed)
+		e.info.SetCurrentPlayerIndex(leader)
+		e.emit(Revealed{Card: revealed, Index: leader})
+	} else {
+		decks = deck.Split(e.info.GetNumPlayers())
+	}
+	for index, hand := range decks {
+		hand.Sort(e.rule, models.SortByStrength)
+		e.players[index].SetHand(hand)
+		e.emit(Dealt{
+			Index:           index,
+			Hand:            append([]models.Card{}, hand.GetCards()...),
+			TrumpRank:       e.info.GetTrumpRank(),
+			FinishedIndexes: lastOrder,
+		})
+	}
+
+	// start the new round with an empty table
+	e.info.ResetFinishedIndexes()
+	e.info.SetLastPlayedCards(nil)
+	e.info.ResetPassedIndexes()
+
+	if newMatch || !e.rule.Profile().Tribute {
+		// without tribute the winner of the last round leads
+		e.startArranging()
+		return
+	}
+	e.startTribute(lastOrder)
+}
+
+// startArranging lets the players arrange their hands before the round starts
+func (e *Engine) startArranging() {
+	e.info.SetLastPlayedIndex(e.info.GetCurrentPlayerIndex())
+	e.info.SetPhase(models.PhaseArranging)
+	e.emit(ArrangingStarted{})
+}
+
+// start marks the player as done arranging cards
+func (e *Engine) start(cmd Start) *models.ProtocolError {
+	if err := e.checkSeated(cmd.Index); err != nil {
+		return err
+	}
+	if e.info.GetPhase() != models.PhaseArranging {
+		return models.NewProtocolError(models.CodeWrongPhase, "cannot start in the %s phase", e.info.GetPhase())
+	}
+	e.markStarted(cmd.Index)
+	return nil
+}
+
+// markStarted records that the player at index is done arranging cards,
+// and starts the round once everybody is
+func (e *Engine) markStarted(index int) {
+	allReady := e.players[index].ReadyToPlay()
+	e.emit(PlayerArranged{Index: index})
+	// if everybody is ready, start the round
+	if allReady {
+		log.Printf("Everybody is ready, starting the round...")
+		e.info.SetPhase(models.PhasePlaying)
+		e.emit(Turn{Index: e.info.GetCurrentPlayerIndex()})
+	}
+}
+
+// play validates a play against the game state and, unless it is a dry run, applies it.
+// The play is refused if it is not the player's turn, the cards are not in the player's hand,
+// the wild card equivalents are illegal, the play does not follow the rules or cannot be read as declared.
+func (e *Engine) play(cmd Play) *models.ProtocolError {
+	equivalent := cmd.Equivalent
+	if len(equivalent) == 0 && cmd.Declared != nil {
+		equivalent = e.declaredReading(cmd.Index, cmd.Cards, *cmd.Declared)
+	}
+	if len(equivalent) == 0 {
+		equivalent = cmd.Cards
+	}
+
+	if err := e.checkPlayAllowed(cmd.Index, cmd.Cards, equivalent, cmd.Declared); err != nil {
+		return err
+	}
+
+	if cmd.DryRun {
+		e.emit(PlayChecked{Index: cmd.Index})
+		return nil
+	}
+	e.applyPlay(cmd.Index, cmd.Cards, equivalent)
+	return nil
+}
+
+// declaredReading returns the equivalent of attempt that forms the declared combination,
+// nil if attempt is not in the hand of the player at index or cannot be read as declared
+func (e *Engine) declaredReading(index int, attempt []models.Card, declared models.Combination) []models.Card {
+	if hand := e.Hand(index); hand == nil || !hand.HasN(attempt) {
+		return nil
+	}
+	for _, reading := range e.rule.Readings(attempt) {
+		if reading.Type == declared.Type && reading.Rank == declared.Rank {
+			return reading.Equivalent
+		}
+	}
+	return nil
+}
+
+// checkPlayAllowed returns nil if the player at index may play attempt, read as equivalent and,
+// if declared is set, forming the declared combination right now, otherwise the reason the play is refused
+func (e *Engine) checkPlayAllowed(index int, attempt []models.Card, equivalent []models.Card, declared *models.Combination) *models.ProtocolError {
+	if e.info.GetPhase() != models.PhasePlaying {
+		return models.NewProtocolError(models.CodeWrongPhase, "cannot play in the %s phase", e.info.GetPhase())
+	}
+	if e.info.GetCurrentPlayerIndex() != index {
+		return models.NewProtocolError(models.CodeNotYourTurn, "it is player %d's turn", e.info.GetCurrentPlayerIndex())
+	}
+	if len(attempt) == 0 {
+		return models.NewProtocolError(models.CodeMalformed, "no cards played")
+	}
+	hand := e.Hand(index)
+	if hand == nil || !hand.HasN(attempt) {
+		return models.NewProtocolError(models.CodeCardsNotInHand, "%s not in hand", models.CardsString(attempt))
+	}
+	if !e.rule.IsEquivalentValid(attempt, equivalent) {
+		return models.NewProtocolError(models.CodeMalformed, "%s cannot stand for %s", models.CardsString(attempt), models.CardsString(equivalent))
+	}
+
+	var err error
+	if e.isLeading(index) {
+		err = e.rule.CheckPlay(equivalent)
+	} else {
+		err = e.rule.CheckCounterPlay(e.info.GetLastPlayedCards(), equivalent)
+	}
+	var perr *models.ProtocolError
+	if errors.As(err, &perr) {
+		return perr
+	}
+	if err != nil {
+		return models.NewProtocolError(models.CodeIllegalCombination, "%v", err)
+	}
+	if declared != nil {
+		if combination, _ := e.rule.Classify(equivalent); combination.Type != declared.Type || combination.Rank != declared.Rank {
+			return models.NewProtocolError(models.CodeIllegalCombination, "%s cannot be read as %s", models.CardsString(attempt), declared)
+		}
+	}
+	return nil
+}
+
+// applyPlay removes attempt from the hand of the player at index, puts its equivalent on the table
+// and moves the turn on. The play must have been checked with checkPlayAllowed.
+func (e *Engine) applyPlay(index int, attempt []models.Card, equivalent []models.Card) {
+	player := e.players[index]
+	// refer to the physical cards of the hand, which the player may have named by value only
+	if physical, ok := player.GetHand().FindN(attempt); ok {
+		equivalent = append([]models.Card{}, equivalent...)
+		for i := range equivalent {
+			if equivalent[i].SameValue(physical[i]) {
+				equivalent[i] = physical[i]
+			}
+		}
+		attempt = physical
+	}
+	player.Play(attempt, equivalent)
+	hand := player.GetHand()
+	log.Printf("Player %d played %s", index, models.CardsString(attempt))
+	e.emit(Played{Index: index, CardsLeft: hand.Count(), Cards: attempt, Equivalent: equivalent})
+
+	if hand.IsEmpty() {
+		log.Printf("Player %d finished", index)
+		e.info.SetFinishedIndexes(append(e.info.GetFinishedIndexes(), index))
+		player.Finish(len(e.info.GetFinishedIndexes()))
+		e.emit(PlayerFinished{Index: index, Place: player.GetFinishedRank()})
+		if len(e.info.GetFinishedIndexes()) == e.info.GetNumPlayers()-1 {
+			log.Printf("Everybody finished, calculating...")
+			e.endRound(append(e.info.GetFinishedIndexes(), e.nextPlayerIndex(index)))
+			return
+		}
+	}
+
+	e.info.SetCurrentPlayerIndex(e.nextPlayerIndex(index))
+	e.emit(Turn{Index: e.info.GetCurrentPlayerIndex()})
+}
+
+// isLeading returns true if the player at index is free to lead a new trick
+func (e *Engine) isLeading(index int) bool {
+	return e.info.GetLastPlayedCards() == nil || e.info.GetLastPlayedIndex() == index
+}
+
+// pass passes the player's turn. A player cannot pass while leading a new trick.
+func (e *Engine) pass(cmd Pass) *models.ProtocolError {
+	switch {
+	case e.info.GetPhase() != models.PhasePlaying:
+		return models.NewProtocolError(models.CodeWrongPhase, "cannot pass in the %s phase", e.info.GetPhase())
+	case e.info.GetCurrentPlayerIndex() != cmd.Index:
+		return models.NewProtocolError(models.CodeNotYourTurn, "it is player %d's turn", e.info.GetCurrentPlayerIndex())
+	case e.isLeading(cmd.Index):
+		return models.NewProtocolError(models.CodeIllegalCombination, "cannot pass when leading a new trick")
+	}
+	e.applyPass(cmd.Index)
+	return nil
+}
+
+// applyPass passes the turn of the player at index to the next player, ending the trick
+// when everybody else passed on the last play
+func (e *Engine) applyPass(index int) {
+	log.Printf("Player %d passed", index)
+	e.players[index].Pass()
+	e.emit(Passed{Index: index})
+	next := e.nextPlayerIndex(index)
+	if e.trickWonBy(index, next) {
+		// everybody passed on the last play, the trick is over
+		winner := e.info.GetLastPlayedIndex()
+		e.info.SetLastPlayedCards(nil)
+		e.info.ResetPassedIndexes()
+		if e.isFinished(winner) {
+			// the winner has already gone out, with jie feng the lead passes to the next partner still playing,
+			// otherwise it stays with the next player after the winner
+			if partner, ok := e.nextPartnerIndex(winner); ok && e.rule.Profile().JieFeng {
+				next = partner
+			}
+		} else {
+			next = winner
+		}
+		e.info.SetLastPlayedIndex(next)
+	}
+	e.info.SetCurrentPlayerIndex(next)
+	e.emit(Turn{Index: e.info.GetCurrentPlayerIndex()})
+}
+
+// trickWonBy returns true if the turn moving from index to next goes past the last player who played,
+// which means every other player still in the round has passed
+func (e *Engine) trickWonBy(index int, next int) bool {
+	numPlayers := e.info.GetNumPlayers()
+	last := e.info.GetLastPlayedIndex()
+	for i := (index + 1) % numPlayers; ; i = (i + 1) % numPlayers {
+		if i == last {
+			return true
+		}
+		if i == next {
+			return false
+		}
+	}
+}
+
+// nextPlayerIndex returns the index of the next player after index who has not finished the round
+func (e *Engine) nextPlayerIndex(index int) int {
+	numPlayers := e.info.GetNumPlayers()
+	next := (index + 1) % numPlayers
+	for next != index && e.isFinished(next) {
+		next = (next + 1) % numPlayers
+	}
+	return next
+}
+
+// nextPartnerIndex returns the index of the next partner of the player at index, going around the table,
+// who has not finished the round, and false if every partner has
+func (e *Engine) nextPartnerIndex(index int) (int, bool) {
+	numPlayers := e.info.GetNumPlayers()
+	team := e.info.GetTeamOf(index)
+	for i := (index + 1) % numPlayers; i != index; i = (i + 1) % numPlayers {
+		if e.info.GetTeamOf(i) == team && !e.isFinished(i) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// isFinished returns true if the player at index has already played all their cards this round
+func (e *Engine) isFinished(index int) bool {
+	for _, i := range e.info.GetFinishedIndexes() {
+		if i == index {
+			return true
+		}
+	}
+	return false
+}
+
+// endRound ends the round with the given finishing order and applies the level result.
+// The winning team's level is the trump rank of the next round, and the match is over if the team
+// won its round at level A going up the levels the table needs to win, with the partner not finishing last at four players.
+// A team playing at level A that does not win the match uses up an attempt, and falls back to level Two
+// after the failed attempts allowed by the rule profile.
+// Seats of away players are freed, and if the table is still full everybody is asked to get ready again.
+func (e *Engine) endRound(order []int) {
+	e.info.SetFinishedIndexes(order)
+	for place, index := range order {
+		if player, ok := e.players[index]; ok && player.GetFinishedRank() == 0 {
+			player.Finish(place + 1)
+		}
+	}
+	team, up := e.rule.LevelUp(order)
+	teams := e.info.GetTeams()
+	atAce := e.info.GetTrumpRank() == models.Ace
+	matchOver := teams[team].Level == models.Ace && atAce && up >= e.info.GetTable().LevelsToWinAtA()
+	for t := range teams {
+		if !atAce || teams[t].Level != models.Ace || matchOver {
+			continue
+		}
+		teams[t].AAttempts++
+		if teams[t].AAttempts >= e.rule.Profile().MaxAAttempts {
+			log.Printf("Team %d failed level A %d times, back to level Two", t, teams[t].AAttempts)
+			teams[t].Level = models.Two
+			teams[t].AAttempts = 0
+		}
+	}
+	teams[team].Level = models.NextLevel(teams[team].Level, up)
+	e.info.SetTeams(teams)
+	levels := make([]models.Rank, len(teams))
+	for t := range teams {
+		levels[t] = teams[t].Level
+	}
+	e.info.SetTrumpRank(teams[team].Level)
+	e.info.SetCurrentPlayerIndex(order[0])
+	e.info.SetIsRoundInSession(false)
+	e.tribute = nil
+	log.Printf("Round over, finishing order %v, team %d goes up %d levels", order, team, up)
+	e.emit(RoundOver{FinishedIndexes: order, Team: team, LevelsUp: up, Levels: levels})
+
+	if matchOver {
+		log.Printf("Team %d won the match", team)
+		e.info.SetPhase(models.PhaseMatchOver)
+		e.emit(MatchOver{Team: team, Levels: levels})
+	} else {
+		e.info.SetPhase(models.PhaseRoundOver)
+	}
+
+	for index := 0; index < e.info.GetNumPlayers(); index++ {
+		if e.isAway(index) {
+			e.freeSeat(index)
+		}
+	}
+	if e.numSeated() == e.info.GetNumPlayers() {
+		e.emit(AllJoined{})
+	}
+}
+
+// leave removes the player from the seat.
+// During a round the seat is kept for the player and the table's leave policy is applied,
+// otherwise the seat is freed.
+func (e *Engine) leave(cmd Leave) *models.ProtocolError {
+	if err := e.checkSeated(cmd.Index); err != nil {
+		return err
+	}
+	if e.info.GetIsRoundInSession() {
+		e.seatLeft(cmd.Index)
+		return nil
+	}
+	e.freeSeat(cmd.Index)
+	return nil
+}
+
+// freeSeat makes the seat at index available to new players
+func (e *Engine) freeSeat(index int) {
+	e.info.FreeSeat(index)
+	delete(e.players, index)
+	for from, to := range e.swapRequests {
+		if from == index || to == index {
+			delete(e.swapRequests, from)
+		}
+	}
+	e.emit(SeatFreed{Index: index})
+}
+
+// seatLeft applies the table's leave policy to the seat at index, whose player left mid-round
+func (e *Engine) seatLeft(index int) {
+	policy := e.info.GetLeavePolicy()
+	log.Printf("Player %d is away, leave policy %s", index, policy)
+	e.players[index].LeaveGame()
+	e.emit(Away{Index: index, Policy: policy})
+
+	if policy == models.LeaveForfeit {
+		e.forfeitRound(index)
+	}
+}
+
+// forfeitRound ends the round with the team of the player at index losing.
+// The players of the other team are ranked ahead of the forfeiting team, keeping the order
+// of the players who already finished.
+func (e *Engine) forfeitRound(index int) {
+	team := e.info.GetTeamOf(index)
+	order := make([]int, 0, e.info.GetNumPlayers())
+	for _, forfeiting := range []bool{false, true} {
+		for _, i := range e.info.GetFinishedIndexes() {
+			if (e.info.GetTeamOf(i) == team) == forfeiting {
+				order = append(order, i)
+			}
+		}
+		for i := 0; i < e.info.GetNumPlayers(); i++ {
+			if (e.info.GetTeamOf(i) == team) == forfeiting && !e.isFinished(i) {
+				order = append(order, i)
+			}
+		}
+	}
+	log.Printf("Team %d forfeits the round", team)
+	e.endRound(order)
+}
+
+// playAwayTurns lets the engine act for away players when the leave policy is LeaveBot.
+// An away player pays its highest card as tribute, returns its lowest card, starts the round
+// right away, leads its lowest card and passes otherwise.
+func (e *Engine) playAwayTurns() {
+	if e.info.GetLeavePolicy() != models.LeaveBot {
+		return
+	}
+	if e.info.GetPhase() == models.PhaseTribute {
+		e.settleAwayTribute()
+	}
+	if e.info.GetPhase() == models.PhaseArranging {
+		for index := 0; index < e.info.GetNumPlayers(); index++ {
+			if e.isAway(index) && !e.info.IsReadyToPlay(index) {
+				e.markStarted(index)
+			}
+		}
+	}
+	for e.info.GetPhase() == models.PhasePlaying && e.isAway(e.info.GetCurrentPlayerIndex()) {
+		index := e.info.GetCurrentPlayerIndex()
+		if e.isLeading(index) {
+			card := e.lowestCard(e.Hand(index).GetCards())
+			e.applyPlay(index, []models.Card{card}, []models.Card{card})
+		} else {
+			e.applyPass(index)
+		}
+	}
+}
+
+// newToken returns a random seat token
+func newToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		log.Printf("Failed to generate seat token: %v", err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// lowestCard returns the weakest card of cards for the current trump rank
+func (e *Engine) lowestCard(cards []models.Card) models.Card {
+	lowest := cards[0]
+	for _, card := range cards[1:] {
+		if e.rule.IsRankGreater(lowest.Rank, card.Rank) {
+			lowest = card
+		}
+	}
+	return lowest
+}
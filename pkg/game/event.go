@@ -0,0 +1,226 @@
+package game
+
+import "github.com/ChengL-cisco/onlineGuanDan/pkg/models"
+
+// Everybody is the recipient of events every player may see
+const Everybody = -1
+
+// Event is a change at the table reported by Engine.Handle
+type Event interface {
+	// Recipient returns the index of the only player who may see the event, or Everybody
+	Recipient() int
+}
+
+// SeatTaken is reported when a player takes a seat
+type SeatTaken struct {
+	Index int
+	Name  string
+}
+
+// TokenIssued is reported to the player at Index with the token to take the seat back with
+type TokenIssued struct {
+	Index int
+	Token string
+}
+
+// PlayerReady is reported when the player at Index is ready for the next round
+type PlayerReady struct {
+	Index int
+}
+
+// PlayerArranged is reported when the player at Index is done arranging cards
+type PlayerArranged struct {
+	Index int
+}
+
+// SeatFreed is reported when a seat becomes available to new players
+type SeatFreed struct {
+	Index int
+}
+
+// SwapRequested is reported when the player at From asks the player at To to swap seats
+type SwapRequested struct {
+	From int
+	To   int
+}
+
+// SeatsSwapped is reported when the player at From moved to the seat at To,
+// and the player at To, if any, moved to the seat at From
+type SeatsSwapped struct {
+	From int
+	To   int
+}
+
+// AllJoined is reported when every seat is taken and players should get ready
+type AllJoined struct{}
+
+// Dealt is reported to each player with the hand dealt to them
+type Dealt struct {
+	Index           int
+	Hand            []models.Card
+	TrumpRank       models.Rank
+	FinishedIndexes []int
+}
+
+// Revealed is reported when Card is turned face up during the deal of the first round of a match,
+// the player at Index received its twin and leads
+type Revealed struct {
+	Card  models.Card
+	Index int
+}
+
+// TributeDue is reported when the player at From has to pay tribute to the player at To.
+// To is Everybody in a double tribute, where the receivers are only known once both tributes are paid.
+type TributeDue struct {
+	From int
+	To   int
+}
+
+// AntiTribute is reported when the players due to pay tribute hold every big joker and pay nothing
+type AntiTribute struct {
+	Indexes []int
+}
+
+// TributePaid is reported when Card moved as tribute from the player at From to the player at To,
+// who then has to return a card
+type TributePaid struct {
+	From int
+	To   int
+	Card models.Card
+}
+
+// CardReturned is reported when Card moved back from the player at From to the player who paid tribute
+type CardReturned struct {
+	From int
+	To   int
+	Card models.Card
+}
+
+// ArrangingStarted is reported when players can arrange their hands before the round starts
+type ArrangingStarted struct{}
+
+// Turn is reported when it is the turn of the player at Index
+type Turn struct {
+	Index int
+}
+
+// Played is reported when the player at Index played Cards read as Equivalent
+type Played struct {
+	Index      int
+	CardsLeft  int
+	Cards      []models.Card
+	Equivalent []models.Card
+}
+
+// PlayChecked is reported to the player at Index when a dry run play is valid
+type PlayChecked struct {
+	Index int
+}
+
+// Passed is reported when the player at Index passed
+type Passed struct {
+	Index int
+}
+
+// PlayerFinished is reported when the player at Index played all their cards, Place starts at 1
+type PlayerFinished struct {
+	Index int
+	Place int
+}
+
+// RoundOver is reported with the finishing order when a round ends, Team went up LevelsUp levels
+type RoundOver struct {
+	FinishedIndexes []int
+	Team            int
+	LevelsUp        int
+	Levels          []models.Rank
+}
+
+// MatchOver is reported when Team won the match
+type MatchOver struct {
+	Team   int
+	Levels []models.Rank
+}
+
+// Away is reported when the player at Index left mid-round and Policy applies to the seat
+type Away struct {
+	Index  int
+	Policy models.LeavePolicy
+}
+
+// Back is reported when an away player took their seat back
+type Back struct {
+	Index int
+}
+
+// Recipient returns Everybody
+func (e SeatTaken) Recipient() int { return Everybody }
+
+// Recipient returns the index of the player the token was issued to
+func (e TokenIssued) Recipient() int { return e.Index }
+
+// Recipient returns Everybody
+func (e PlayerReady) Recipient() int { return Everybody }
+
+// Recipient returns Everybody
+func (e PlayerArranged) Recipient() int { return Everybody }
+
+// Recipient returns Everybody
+func (e SeatFreed) Recipient() int { return Everybody }
+
+// Recipient returns Everybody
+func (e SwapRequested) Recipient() int { return Everybody }
+
+// Recipient returns Everybody
+func (e SeatsSwapped) Recipient() int { return Everybody }
+
+// Recipient returns Everybody
+func (e AllJoined) Recipient() int { return Everybody }
+
+// Recipient returns the index of the player the hand was dealt to
+func (e Dealt) Recipient() int { return e.Index }
+
+// Recipient returns Everybody
+func (e Revealed) Recipient() int { return Everybody }
+
+// Recipient returns Everybody
+func (e TributeDue) Recipient() int { return Everybody }
+
+// Recipient returns Everybody
+func (e AntiTribute) Recipient() int { return Everybody }
+
+// Recipient returns Everybody
+func (e TributePaid) Recipient() int { return Everybody }
+
+// Recipient returns Everybody
+func (e CardReturned) Recipient() int { return Everybody }
+
+// Recipient returns Everybody
+func (e ArrangingStarted) Recipient() int { return Everybody }
+
+// Recipient returns Everybody
+func (e Turn) Recipient() int { return Everybody }
+
+// Recipient returns Everybody
+func (e Played) Recipient() int { return Everybody }
+
+// Recipient returns the index of the player who asked for the check
+func (e PlayChecked) Recipient() int { return e.Index }
+
+// Recipient returns Everybody
+func (e Passed) Recipient() int { return Everybody }
+
+// Recipient returns Everybody
+func (e PlayerFinished) Recipient() int { return Everybody }
+
+// Recipient returns Everybody
+func (e RoundOver) Recipient() int { return Everybody }
+
+// Recipient returns Everybody
+func (e MatchOver) Recipient() int { return Everybody }
+
+// Recipient returns Everybody
+func (e Away) Recipient() int { return Everybody }
+
+// Recipient returns Everybody
+func (e Back) Recipient() int { return Everybody }
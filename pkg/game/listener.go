@@ -0,0 +1,48 @@
+package game
+
+// GameListener is notified of every event at a table, such as a seat taken, a deal, a play or the end of a round.
+// Listeners are called synchronously by Engine.Handle, in the order they were added, once the command is applied.
+// Every listener sees every event, including the events only one player may see, so a listener forwarding
+// events to players must respect Event.Recipient. A listener must not call Handle on the engine notifying it.
+type GameListener interface {
+	OnEvent(event Event)
+}
+
+// CommandListener is a GameListener also told when every event of a command has been notified,
+// for listeners doing their work once per command rather than once per event
+type CommandListener interface {
+	GameListener
+	// OnCommandDone is called after the events of a command accepted with at least one event
+	OnCommandDone()
+}
+
+// ListenerFunc adapts a function to a GameListener
+type ListenerFunc func(event Event)
+
+// OnEvent calls f(event)
+func (f ListenerFunc) OnEvent(event Event) {
+	f(event)
+}
+
+// AddListener registers a listener to be notified of the events at the table
+func (e *Engine) AddListener(listener GameListener) {
+	e.listeners = append(e.listeners, listener)
+}
+
+// notify passes the events to every listener, each event to all listeners before the next one,
+// then tells the command listeners the command is done
+func (e *Engine) notify(events []Event) {
+	if len(events) == 0 {
+		return
+	}
+	for _, event := range events {
+		for _, listener := range e.listeners {
+			listener.OnEvent(event)
+		}
+	}
+	for _, listener := range e.listeners {
+		if done, ok := listener.(CommandListener); ok {
+			done.OnCommandDone()
+		}
+	}
+}
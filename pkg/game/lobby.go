@@ -0,0 +1,81 @@
+package game
+
+import (
+	"log"
+
+	"github.com/ChengL-cisco/onlineGuanDan/pkg/models"
+)
+
+// checkLobby returns an error if players cannot change seats in the current phase,
+// which they can only do before a match starts
+func (e *Engine) checkLobby() *models.ProtocolError {
+	switch e.info.GetPhase() {
+	case models.PhaseLobby, models.PhaseMatchOver:
+		return nil
+	default:
+		return models.NewProtocolError(models.CodeWrongPhase, "cannot change seats in the %s phase", e.info.GetPhase())
+	}
+}
+
+// chooseTeam moves the player to the first free seat of the chosen team
+func (e *Engine) chooseTeam(cmd ChooseTeam) *models.ProtocolError {
+	if err := e.checkSeated(cmd.Index); err != nil {
+		return err
+	}
+	if err := e.checkLobby(); err != nil {
+		return err
+	}
+	teams := e.info.GetTeams()
+	if cmd.Team < 0 || cmd.Team >= len(teams) {
+		return models.NewProtocolError(models.CodeMalformed, "no team %d", cmd.Team)
+	}
+	if teams[cmd.Team].HasMember(cmd.Index) {
+		return nil
+	}
+	for _, seat := range teams[cmd.Team].Members {
+		if _, taken := e.players[seat]; !taken {
+			e.moveSeats(cmd.Index, seat)
+			return nil
+		}
+	}
+	return models.NewProtocolError(models.CodeSeatTaken, "team %s has no free seat", teams[cmd.Team].Name)
+}
+
+// swapSeats moves the player to the seat asked for if it is free, otherwise it asks the player
+// in that seat to swap and swaps the two players once both asked for it
+func (e *Engine) swapSeats(cmd SwapSeats) *models.ProtocolError {
+	if err := e.checkSeated(cmd.Index); err != nil {
+		return err
+	}
+	if err := e.checkLobby(); err != nil {
+		return err
+	}
+	if cmd.With < 0 || cmd.With >= e.info.GetNumPlayers() || cmd.With == cmd.Index {
+		return models.NewProtocolError(models.CodeMalformed, "cannot swap seat %d with seat %d", cmd.Index, cmd.With)
+	}
+	if _, taken := e.players[cmd.With]; !taken || e.swapRequests[cmd.With] == cmd.Index {
+		e.moveSeats(cmd.Index, cmd.With)
+		return nil
+	}
+	e.swapRequests[cmd.Index] = cmd.With
+	e.emit(SwapRequested{From: cmd.Index, To: cmd.With})
+	return nil
+}
+
+// moveSeats moves the player at from to the seat at to, and the player at to, if any, to the seat at from
+func (e *Engine) moveSeats(from int, to int) {
+	log.Printf("Player %d moves to seat %d", from, to)
+	e.info.SwapSeats(from, to)
+	moved, other := e.players[from], e.players[to]
+	delete(e.players, from)
+	delete(e.players, to)
+	moved.Sit(to)
+	e.players[to] = moved
+	if other != nil {
+		other.Sit(from)
+		e.players[from] = other
+	}
+	// the swaps asked for were for the seats as they were
+	e.swapRequests = make(map[int]int)
+	e.emit(SeatsSwapped{From: from, To: to})
+}
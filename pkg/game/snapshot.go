@@ -0,0 +1,162 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/ChengL-cisco/onlineGuanDan/pkg/models"
+)
+
+// Snapshot is the complete state of a table, from which an engine can be restored
+type Snapshot struct {
+	NumPlayers      int                        `json:"numPlayers"`
+	Table           models.TableConfig         `json:"table"`
+	Profile         models.RuleProfile         `json:"profile"`
+	LeavePolicy     models.LeavePolicy         `json:"leavePolicy"`
+	Phase           models.Phase               `json:"phase"`
+	FirstRound      bool                       `json:"firstRound"`
+	RoundInSession  bool                       `json:"roundInSession"`
+	Names           map[int]string             `json:"names"`
+	Tokens          map[int]string             `json:"tokens"`
+	ReadyToStart    []int                      `json:"readyToStart"`
+	ReadyToPlay     []int                      `json:"readyToPlay"`
+	TrumpRank       models.Rank                `json:"trumpRank"`
+	Teams           []models.Team              `json:"teams"`
+	CurrentIndex    int                        `json:"currentIndex"`
+	LastPlayedCards []models.Card              `json:"lastPlayedCards"`
+	LastPlayedIndex int                        `json:"lastPlayedIndex"`
+	PassedIndexes   []int                      `json:"passedIndexes"`
+	FinishedIndexes []int                      `json:"finishedIndexes"`
+	Hands           map[int][]models.Card      `json:"hands"`
+	Stats           map[int]models.PlayerStats `json:"stats"`
+	Tribute         *TributeSnapshot           `json:"tribute,omitempty"`
+}
+
+// TributeSnapshot is the state of the tribute of the current round
+type TributeSnapshot struct {
+	Payers    []int               `json:"payers"`
+	Receivers []int               `json:"receivers"`
+	Paid      map[int]models.Card `json:"paid"`
+	Owed      map[int]int         `json:"owed"`
+	Leader    int                 `json:"leader"`
+}
+
+// Snapshot returns a copy of the complete state of the table
+func (e *Engine) Snapshot() *Snapshot {
+	info := e.info.Snapshot()
+	s := &Snapshot{
+		NumPlayers:      info.NumPlayers,
+		Table:           e.info.GetTable(),
+		Profile:         e.rule.Profile(),
+		LeavePolicy:     info.LeavePolicy,
+		Phase:           info.Phase,
+		FirstRound:      e.firstRound,
+		RoundInSession:  info.IsRoundInSession,
+		Names:           info.Names,
+		Tokens:          make(map[int]string),
+		TrumpRank:       info.TrumpRank,
+		Teams:           info.Teams,
+		CurrentIndex:    info.CurrentPlayerIndex,
+		LastPlayedCards: info.LastPlayedCards,
+		LastPlayedIndex: info.LastPlayedIndex,
+		PassedIndexes:   info.PassedIndexes,
+		FinishedIndexes: info.FinishedIndexes,
+		Hands:           make(map[int][]models.Card),
+		Stats:           make(map[int]models.PlayerStats),
+	}
+	for index := 0; index < s.NumPlayers; index++ {
+		if info.ReadyToStart[index] {
+			s.ReadyToStart = append(s.ReadyToStart, index)
+		}
+		if info.ReadyToPlay[index] {
+			s.ReadyToPlay = append(s.ReadyToPlay, index)
+		}
+	}
+	for index, player := range e.players {
+		s.Tokens[index] = player.GetToken()
+		s.Hands[index] = append([]models.Card{}, player.GetHand().GetCards()...)
+		s.Stats[index] = player.GetStats()
+	}
+	if t := e.tribute; t != nil {
+		s.Tribute = &TributeSnapshot{
+			Payers:    append([]int{}, t.payers...),
+			Receivers: append([]int{}, t.receivers...),
+			Paid:      make(map[int]models.Card),
+			Owed:      make(map[int]int),
+			Leader:    t.leader,
+		}
+		for index, card := range t.paid {
+			s.Tribute.Paid[index] = card
+		}
+		for index, payer := range t.owed {
+			s.Tribute.Owed[index] = payer
+		}
+	}
+	return s
+}
+
+// RestoreEngine creates an engine continuing the table of the snapshot.
+// Every seated player is away until they join their seat again with its token, or under the same name if rejoinByName.
+// A snapshot without a table configuration is restored with the usual configuration for its number of players.
+func RestoreEngine(s *Snapshot, rejoinByName bool) (*Engine, error) {
+	table := s.Table
+	if table.NumPlayers == 0 {
+		table, _ = models.NewTableConfig(s.NumPlayers, 0)
+	}
+	if err := table.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to restore the table: %w", err)
+	}
+	e := NewEngine(Config{Table: table, Profile: s.Profile, LeavePolicy: s.LeavePolicy, RejoinByName: rejoinByName})
+	e.firstRound = s.FirstRound
+	e.info.SetPhase(s.Phase)
+	e.info.SetIsRoundInSession(s.RoundInSession)
+	e.info.SetTrumpRank(s.TrumpRank)
+	if s.Teams != nil {
+		e.info.SetTeams(s.Teams)
+	}
+	e.info.SetCurrentPlayerIndex(s.CurrentIndex)
+	e.info.SetLastPlayedCards(s.LastPlayedCards)
+	e.info.SetLastPlayedIndex(s.LastPlayedIndex)
+	e.info.SetFinishedIndexes(s.FinishedIndexes)
+	for _, index := range s.PassedIndexes {
+		e.info.AddPassedIndex(index)
+	}
+
+	for index, name := range s.Names {
+		e.info.TakeSeat(index, name)
+		player := models.NewPlayer(index, name, e.info)
+		player.SetToken(s.Tokens[index])
+		player.SetStats(s.Stats[index])
+		for _, card := range s.Hands[index] {
+			player.GetHand().Add(card)
+		}
+		player.LeaveGame()
+		e.players[index] = player
+	}
+	for place, index := range s.FinishedIndexes {
+		if player, ok := e.players[index]; ok {
+			player.SetFinishedRank(place + 1)
+		}
+	}
+	for _, index := range s.ReadyToStart {
+		e.info.MarkReady(index)
+	}
+	for _, index := range s.ReadyToPlay {
+		e.info.AddReadyToPlay(index)
+	}
+	if t := s.Tribute; t != nil {
+		e.tribute = &tributeRound{
+			payers:    t.Payers,
+			receivers: t.Receivers,
+			paid:      make(map[int]models.Card),
+			owed:      make(map[int]int),
+			leader:    t.Leader,
+		}
+		for index, card := range t.Paid {
+			e.tribute.paid[index] = card
+		}
+		for index, payer := range t.Owed {
+			e.tribute.owed[index] = payer
+		}
+	}
+	return e, nil
+}
@@ -0,0 +1,83 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// FileStore keeps the snapshot of a table in a JSON file
+type FileStore struct {
+	path string
+}
+
+// NewFileStore creates a store keeping the snapshot of a table in the file at path
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Save writes the snapshot to the file. The snapshot is written to a temporary file first
+// and renamed over the old one, so a crash never leaves a partly written snapshot behind.
+func (s *FileStore) Save(snapshot *Snapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+	return nil
+}
+
+// Load reads the snapshot from the file, it returns an error wrapping os.ErrNotExist if there is none
+func (s *FileStore) Load() (*Snapshot, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot %s: %w", s.path, err)
+	}
+	return &snapshot, nil
+}
+
+// Listener returns a listener saving the snapshot of the engine's table once after every command changing it
+func (s *FileStore) Listener(e *Engine) GameListener {
+	return &storeListener{store: s, engine: e}
+}
+
+// storeListener saves the table of engine to store when a command is done
+type storeListener struct {
+	store  *FileStore
+	engine *Engine
+}
+
+// OnEvent does nothing, the table is saved once all the events of the command are notified
+func (l *storeListener) OnEvent(event Event) {}
+
+// OnCommandDone saves the table
+func (l *storeListener) OnCommandDone() {
+	if err := l.store.Save(l.engine.Snapshot()); err != nil {
+		log.Printf("Failed to save table: %v", err)
+	}
+}
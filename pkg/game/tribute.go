@@ -0,0 +1,234 @@
+package game
+
+import (
+	"log"
+	"sort"
+
+	"github.com/ChengL-cisco/onlineGuanDan/pkg/models"
+)
+
+// tributeRound tracks the tribute paid at the start of a round
+type tributeRound struct {
+	// payers are the indexes of the players paying tribute, the last player of the last round first
+	payers []int
+	// receivers are the indexes of the players receiving tribute, the winner of the last round first
+	receivers []int
+	// paid maps each payer who paid to the card they paid
+	paid map[int]models.Card
+	// owed maps each receiver who still has to return a card to the payer it goes back to
+	owed map[int]int
+	// leader is the index of the player who leads the round, the payer of the larger tribute
+	leader int
+}
+
+// startTribute starts the tribute for the finishing order of the last round.
+// The last player pays the winner, or if the winners finished first and second, the last two players
+// pay them both. Every partner of the winner finishing right behind the winner gets a tribute,
+// paid by the last players of the other teams. Payers holding every big joker between them pay nothing
+// and the winner leads.
+func (e *Engine) startTribute(lastOrder []int) {
+	n := len(lastOrder)
+	if n < 2 {
+		e.startArranging()
+		return
+	}
+
+	t := &tributeRound{
+		paid: make(map[int]models.Card),
+		owed: make(map[int]int),
+	}
+	winners := e.info.GetTeamOf(lastOrder[0])
+	k := 1
+	for k < n && e.info.GetTeamOf(lastOrder[k]) == winners {
+		k++
+	}
+	t.receivers = append([]int{}, lastOrder[:k]...)
+	if k == 1 {
+		t.payers = []int{lastOrder[n-1]}
+	}
+	for i := n - 1; i >= k && len(t.payers) < k; i-- {
+		if e.info.GetTeamOf(lastOrder[i]) != winners {
+			t.payers = append(t.payers, lastOrder[i])
+		}
+	}
+
+	bigJokers := 0
+	for _, payer := range t.payers {
+		for _, card := range e.Hand(payer).GetCards() {
+			if card.Rank == models.BigJoker {
+				bigJokers++
+			}
+		}
+	}
+	if bigJokers >= e.info.GetTable().NumDecks {
+		log.Printf("Players %v hold every big joker, no tribute", t.payers)
+		e.emit(AntiTribute{Indexes: t.payers})
+		e.info.SetCurrentPlayerIndex(lastOrder[0])
+		e.startArranging()
+		return
+	}
+
+	e.tribute = t
+	e.info.SetPhase(models.PhaseTribute)
+	for _, payer := range t.payers {
+		to := t.receivers[0]
+		if len(t.payers) > 1 {
+			// in a double tribute the larger tribute goes to the winner, which is known once every tribute is paid
+			to = Everybody
+		}
+		e.emit(TributeDue{From: payer, To: to})
+	}
+}
+
+// payTribute pays the player's tribute, which must be the highest card in the hand other than a wild card
+func (e *Engine) payTribute(cmd Tribute) *models.ProtocolError {
+	if err := e.checkSeated(cmd.Index); err != nil {
+		return err
+	}
+	if e.info.GetPhase() != models.PhaseTribute {
+		return models.NewProtocolError(models.CodeWrongPhase, "cannot pay tribute in the %s phase", e.info.GetPhase())
+	}
+	t := e.tribute
+	if _, paid := t.paid[cmd.Index]; paid || !contains(t.payers, cmd.Index) {
+		return models.NewProtocolError(models.CodeIllegalTribute, "player %d owes no tribute", cmd.Index)
+	}
+	hand := e.Hand(cmd.Index)
+	if !hand.HasN([]models.Card{cmd.Card}) {
+		return models.NewProtocolError(models.CodeCardsNotInHand, "%s not in hand", cmd.Card.CardString())
+	}
+	highest := e.highestTributeCard(hand.GetCards())
+	if e.rule.IsWildCard(cmd.Card) || e.rule.IsRankGreater(highest.Rank, cmd.Card.Rank) {
+		return models.NewProtocolError(models.CodeIllegalTribute, "tribute must be your highest card other than a wild card, %s", highest.CardString())
+	}
+	e.applyTribute(cmd.Index, cmd.Card)
+	return nil
+}
+
+// applyTribute takes the tribute card from the payer at index, and once every payer has paid,
+// hands the tribute to the receivers in finishing order, the largest tribute to the winner of the last round
+func (e *Engine) applyTribute(index int, card models.Card) {
+	t := e.tribute
+	card = e.physicalCard(index, card)
+	e.Hand(index).Play(card)
+	t.paid[index] = card
+	if len(t.paid) < len(t.payers) {
+		return
+	}
+
+	payers := append([]int{}, t.payers...)
+	sort.SliceStable(payers, func(i, j int) bool {
+		return e.rule.IsRankGreater(t.paid[payers[i]].Rank, t.paid[payers[j]].Rank)
+	})
+	t.leader = payers[0]
+	for i, payer := range payers {
+		receiver := t.receivers[i]
+		e.Hand(receiver).Add(t.paid[payer])
+		t.owed[receiver] = payer
+		log.Printf("Player %d paid %s to player %d", payer, t.paid[payer].CardString(), receiver)
+		e.emit(TributePaid{From: payer, To: receiver, Card: t.paid[payer]})
+	}
+}
+
+// returnCard gives a card back to the player who paid tribute to the player,
+// the card must be of rank 10 or lower unless the hand holds no such card
+func (e *Engine) returnCard(cmd Return) *models.ProtocolError {
+	if err := e.checkSeated(cmd.Index); err != nil {
+		return err
+	}
+	if e.info.GetPhase() != models.PhaseTribute {
+		return models.NewProtocolError(models.CodeWrongPhase, "cannot return a card in the %s phase", e.info.GetPhase())
+	}
+	if _, owes := e.tribute.owed[cmd.Index]; !owes {
+		return models.NewProtocolError(models.CodeIllegalTribute, "player %d has no card to return", cmd.Index)
+	}
+	hand := e.Hand(cmd.Index)
+	if !hand.HasN([]models.Card{cmd.Card}) {
+		return models.NewProtocolError(models.CodeCardsNotInHand, "%s not in hand", cmd.Card.CardString())
+	}
+	if cmd.Card.Rank > models.Ten && e.returnableCard(hand.GetCards()).Rank <= models.Ten {
+		return models.NewProtocolError(models.CodeIllegalTribute, "returned card must be of rank 10 or lower")
+	}
+	e.applyReturn(cmd.Index, cmd.Card)
+	return nil
+}
+
+// applyReturn moves the returned card from the receiver at index to the payer,
+// and starts arranging once every card is returned
+func (e *Engine) applyReturn(index int, card models.Card) {
+	t := e.tribute
+	payer := t.owed[index]
+	card = e.physicalCard(index, card)
+	e.Hand(index).Play(card)
+	e.Hand(payer).Add(card)
+	delete(t.owed, index)
+	log.Printf("Player %d returned %s to player %d", index, card.CardString(), payer)
+	e.emit(CardReturned{From: index, To: payer, Card: card})
+
+	if len(t.owed) == 0 {
+		e.tribute = nil
+		e.info.SetCurrentPlayerIndex(t.leader)
+		e.startArranging()
+	}
+}
+
+// settleAwayTribute pays the tribute and returns the cards of away players
+func (e *Engine) settleAwayTribute() {
+	t := e.tribute
+	for _, payer := range t.payers {
+		if _, paid := t.paid[payer]; !paid && e.isAway(payer) {
+			e.applyTribute(payer, e.highestTributeCard(e.Hand(payer).GetCards()))
+		}
+	}
+	for _, receiver := range t.receivers {
+		if _, owes := t.owed[receiver]; owes && e.isAway(receiver) && e.tribute != nil {
+			e.applyReturn(receiver, e.returnableCard(e.Hand(receiver).GetCards()))
+		}
+	}
+}
+
+// physicalCard returns the card of the hand of the player at index that card names,
+// which the player may have named by value only
+func (e *Engine) physicalCard(index int, card models.Card) models.Card {
+	if physical, ok := e.Hand(index).FindN([]models.Card{card}); ok {
+		return physical[0]
+	}
+	return card
+}
+
+// highestTributeCard returns the strongest card of cards other than a wild card
+func (e *Engine) highestTributeCard(cards []models.Card) models.Card {
+	var highest models.Card
+	for _, card := range cards {
+		if e.rule.IsWildCard(card) {
+			continue
+		}
+		if highest.Rank == 0 || e.rule.IsRankGreater(card.Rank, highest.Rank) {
+			highest = card
+		}
+	}
+	return highest
+}
+
+// returnableCard returns the lowest card of cards of rank 10 or lower, or the lowest card if there is none
+func (e *Engine) returnableCard(cards []models.Card) models.Card {
+	low := make([]models.Card, 0, len(cards))
+	for _, card := range cards {
+		if card.Rank <= models.Ten {
+			low = append(low, card)
+		}
+	}
+	if len(low) == 0 {
+		return e.lowestCard(cards)
+	}
+	return e.lowestCard(low)
+}
+
+// contains returns true if indexes holds index
+func contains(indexes []int, index int) bool {
+	for _, i := range indexes {
+		if i == index {
+			return true
+		}
+	}
+	return false
+}
@@ -40,78 +40,36 @@ const (
 )
 
 // Card represents a playing card with a suit and rank
+// A dealt card also carries the number of the deck it comes from, which tells the copies of a card apart
+// when several decks are shuffled together. Cards compare by value with Value or SameValue.
 type Card struct {
 	Suit Suit
 	Rank Rank
+	// Deck is the number of the deck the card comes from starting at 1, 0 for a card value such as a wild card equivalent
+	Deck int
 }
 
-// SuitToInitial returns the single-letter string representation of a suit
-// S for Spade, H for Heart, D for Diamond, and C for Club
-func SuitToInitial(s Suit) string {
-	switch s {
-	case Spade:
-		return "S"
-	case Heart:
-		return "H"
-	case Diamond:
-		return "D"
-	case Club:
-		return "C"
-	default:
-		return "?"
-	}
+// Value returns the card without its deck number, the card value rules look at
+func (c Card) Value() Card {
+	c.Deck = 0
+	return c
 }
 
-func RankToString(r Rank) string {
-	switch r {
-	case Jack:
-		return "J"
-	case Queen:
-		return "Q"
-	case King:
-		return "K"
-	case Ace:
-		return "A"
-	default:
-		return fmt.Sprintf("%d", r)
-	}
+// SameValue returns true if both cards have the same suit and rank, whatever deck they come from
+func (c Card) SameValue(other Card) bool {
+	return c.Value() == other.Value()
 }
 
-// StringToRank converts a string representation of a card rank to a Rank type
-func StringToRank(s string) (Rank, error) {
-	switch strings.ToUpper(s) {
-	case "2":
-		return Two, nil
-	case "3":
-		return Three, nil
-	case "4":
-		return Four, nil
-	case "5":
-		return Five, nil
-	case "6":
-		return Six, nil
-	case "7":
-		return Seven, nil
-	case "8":
-		return Eight, nil
-	case "9":
-		return Nine, nil
-	case "10":
-		return Ten, nil
-	case "J":
-		return Jack, nil
-	case "Q":
-		return Queen, nil
-	case "K":
-		return King, nil
-	case "A":
-		return Ace, nil
-	case "JR":
-		return Joker, nil
-	case "BJR":
-		return BigJoker, nil
+// matches returns true if other is this card: the same physical card if this card has a deck number,
+// any copy of the same value otherwise, and any card of the same rank if this card has no suit, as ParseHand reads it
+func (c Card) matches(other Card) bool {
+	switch {
+	case c.Deck != 0:
+		return c == other
+	case c.Suit == "":
+		return c.Rank == other.Rank
 	default:
-		return 0, fmt.Errorf("invalid rank: %s", s)
+		return c.SameValue(other)
 	}
 }
 
@@ -138,26 +96,10 @@ func (c Card) String() string {
 	}
 }
 
+// CardString returns the protocol notation of the card, as in "A-S",
+// followed by the deck number of a dealt card, as in "A-S#2"
 func (c Card) CardString() string {
-	if c.Rank == Joker {
-		return "Jr"
-	}
-	if c.Rank == BigJoker {
-		return "BJr"
-	}
-
-	switch c.Rank {
-	case Jack:
-		return fmt.Sprintf("J-%s", SuitToInitial(c.Suit))
-	case Queen:
-		return fmt.Sprintf("Q-%s", SuitToInitial(c.Suit))
-	case King:
-		return fmt.Sprintf("K-%s", SuitToInitial(c.Suit))
-	case Ace:
-		return fmt.Sprintf("A-%s", SuitToInitial(c.Suit))
-	default:
-		return fmt.Sprintf("%d-%s", c.Rank, SuitToInitial(c.Suit))
-	}
+	return FormatCard(c, NotationProtocol)
 }
 
 // CardsString returns a formatted string representation of a slice of cards
@@ -179,62 +121,7 @@ func CardsString(cards []Card) string {
 	return result.String()
 }
 
-// parseCard parses a card string into a Card struct
-// Supported formats: "2-S", "J-H", "Q-D", "K-C", "A-S", "Jr", "BJr"
-func parseCard(cardStr string) (Card, error) {
-	// Handle jokers
-	switch cardStr {
-	case "Jr":
-		return Card{Rank: Joker}, nil
-	case "BJr":
-		return Card{Rank: BigJoker}, nil
-	}
-
-	// Parse rank and suit
-	parts := strings.Split(cardStr, "-")
-	if len(parts) != 2 {
-		return Card{}, fmt.Errorf("invalid card format: %s", cardStr)
-	}
-
-	rankStr, suitStr := parts[0], parts[1]
-
-	// Parse rank
-	var rank Rank
-	switch rankStr {
-	case "J":
-		rank = Jack
-	case "Q":
-		rank = Queen
-	case "K":
-		rank = King
-	case "A":
-		rank = Ace
-	default:
-		_, err := fmt.Sscanf(rankStr, "%d", &rank)
-		if err != nil || rank < Two || rank > Ten {
-			return Card{}, fmt.Errorf("invalid rank: %s", rankStr)
-		}
-	}
-
-	// Parse suit
-	var suit Suit
-	switch suitStr {
-	case "S":
-		suit = Spade
-	case "H":
-		suit = Heart
-	case "D":
-		suit = Diamond
-	case "C":
-		suit = Club
-	default:
-		return Card{}, fmt.Errorf("invalid suit: %s", suitStr)
-	}
-
-	return NewCard(suit, rank), nil
-}
-
-// NewDeckFromString creates a new deck from a space-separated string of cards
+// NewDeckFromString creates a new deck from a space-separated string of cards in any notation ParseCard reads
 // Example: "2-S 3-H K-D A-C BJr"
 func NewDeckFromString(cardsStr string) (*Deck, error) {
 	if cardsStr == "" {
@@ -245,7 +132,7 @@ func NewDeckFromString(cardsStr string) (*Deck, error) {
 	cards := make([]Card, 0, len(cardStrs))
 
 	for _, cardStr := range cardStrs {
-		card, err := parseCard(cardStr)
+		card, err := ParseCard(cardStr)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse card '%s': %w", cardStr, err)
 		}
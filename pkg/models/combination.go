@@ -0,0 +1,234 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// CombinationType is the kind of combination a play forms
+type CombinationType string
+
+// Constants for combination types
+const (
+	CombinationSingle        CombinationType = "single"        // one card
+	CombinationPair          CombinationType = "pair"          // two cards of the same rank
+	CombinationTriple        CombinationType = "triple"        // three cards of the same rank
+	CombinationFullHouse     CombinationType = "fullHouse"     // a triple and a pair
+	CombinationStraight      CombinationType = "straight"      // five cards of consecutive ranks
+	CombinationTube          CombinationType = "tube"          // three pairs of consecutive ranks
+	CombinationPlate         CombinationType = "plate"         // two triples of consecutive ranks
+	CombinationBomb          CombinationType = "bomb"          // four or more cards of the same rank
+	CombinationStraightFlush CombinationType = "straightFlush" // a straight of a single suit
+)
+
+// IsRun returns true for the combinations of consecutive ranks, in which the trump rank keeps its natural place
+func (t CombinationType) IsRun() bool {
+	switch t {
+	case CombinationStraight, CombinationTube, CombinationPlate, CombinationStraightFlush:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsBomb returns true for the combinations that beat any other combination
+func (t CombinationType) IsBomb() bool {
+	return t == CombinationBomb || t == CombinationStraightFlush
+}
+
+// Combination is a legal play read as a combination type and the rank that decides its strength
+type Combination struct {
+	// Type is the kind of combination
+	Type CombinationType
+	// Rank is the key rank: the rank of the cards for singles, pairs, triples and bombs,
+	// the rank of the triple for full houses and the top rank for runs
+	Rank Rank
+	// Size is the number of cards
+	Size int
+}
+
+// String returns the combination as declared in play messages, "type:rank" as in "straight:10"
+func (c Combination) String() string {
+	return fmt.Sprintf("%s:%s", c.Type, RankToString(c.Rank))
+}
+
+// ParseCombination parses a combination declared as "type:rank" for a play of size cards
+func ParseCombination(s string, size int) (Combination, error) {
+	typeStr, rankStr, ok := strings.Cut(s, ":")
+	if !ok {
+		return Combination{}, fmt.Errorf("invalid combination format: expected type:rank, got %s", s)
+	}
+	t := CombinationType(typeStr)
+	switch t {
+	case CombinationSingle, CombinationPair, CombinationTriple, CombinationFullHouse, CombinationStraight,
+		CombinationTube, CombinationPlate, CombinationBomb, CombinationStraightFlush:
+	default:
+		return Combination{}, fmt.Errorf("invalid combination type: %s", typeStr)
+	}
+	rank, err := ParseRank(rankStr)
+	if err != nil {
+		return Combination{}, err
+	}
+	return Combination{Type: t, Rank: rank, Size: size}, nil
+}
+
+// Reading is a way to read a play: the combination it forms and the cards its wild cards stand for
+type Reading struct {
+	Combination
+	// Equivalent is the play with each wild card replaced by the card it stands for
+	Equivalent []Card
+}
+
+// Classify returns the combination formed by a legal play, or the error CheckPlay returns for an illegal one.
+// Five or more cards of one rank are read as a bomb and a straight of one suit as a straight flush.
+func (r *Rule) Classify(play []Card) (Combination, error) {
+	if err := r.CheckPlay(play); err != nil {
+		return Combination{}, err
+	}
+	combination := Combination{Rank: play[0].Rank, Size: len(play)}
+	switch {
+	case r.allSameRank(play) && len(play) >= 4:
+		combination.Type = CombinationBomb
+	case len(play) == 1:
+		combination.Type = CombinationSingle
+	case len(play) == 2:
+		combination.Type = CombinationPair
+	case len(play) == 3:
+		combination.Type = CombinationTriple
+	case r.isFullHouse(play):
+		combination.Type, combination.Rank = CombinationFullHouse, r.fullHouseTriple(play)
+	case r.isStraightFlush(play):
+		combination.Type, combination.Rank = CombinationStraightFlush, r.runTopOf(play)
+	case r.isStraight(play):
+		combination.Type, combination.Rank = CombinationStraight, r.runTopOf(play)
+	case r.isTube(play):
+		combination.Type, combination.Rank = CombinationTube, r.runTopOf(play)
+	case r.isPlate(play):
+		combination.Type, combination.Rank = CombinationPlate, r.runTopOf(play)
+	}
+	return combination, nil
+}
+
+// CompareRank compares two key ranks of combinations of type t.
+// It returns a positive number if rank1 is stronger, a negative number if rank2 is stronger and 0 if they are equal.
+// The trump rank ranks above the ace and below the jokers, except in runs where it keeps its natural place.
+func (r *Rule) CompareRank(t CombinationType, rank1 Rank, rank2 Rank) int {
+	if t.IsRun() {
+		return int(rank1) - int(rank2)
+	}
+	switch {
+	case rank1 == rank2:
+		return 0
+	case r.IsRankGreater(rank1, rank2):
+		return 1
+	default:
+		return -1
+	}
+}
+
+// Beats returns true if counter beats play.
+// A bomb beats any other combination, and a stronger bomb beats a weaker one. Otherwise counter must be
+// of the same type and size as play with a stronger key rank.
+func (r *Rule) Beats(play Combination, counter Combination) bool {
+	if counter.Type.IsBomb() || play.Type.IsBomb() {
+		if !counter.Type.IsBomb() {
+			return false
+		}
+		if !play.Type.IsBomb() {
+			return true
+		}
+		if p, c := r.bombStrength(play), r.bombStrength(counter); p != c {
+			return c > p
+		}
+	} else if counter.Type != play.Type || counter.Size != play.Size {
+		return false
+	}
+	return r.CompareRank(counter.Type, counter.Rank, play.Rank) > 0
+}
+
+// bombStrength orders the bombs regardless of their key rank: a bomb of more cards is stronger, and a straight
+// flush ranks between bombs of five and six cards with the standard bomb order or above every bomb otherwise
+func (r *Rule) bombStrength(c Combination) int {
+	if c.Type != CombinationStraightFlush {
+		return 2 * c.Size
+	}
+	if r.Profile().BombOrder == BombOrderFlushHigh {
+		return math.MaxInt
+	}
+	return 2*5 + 1
+}
+
+// Readings returns every combination play can legally be read as, bombs first and then the strongest first,
+// each with the cards its wild cards stand for. A play without wild cards has at most one reading.
+func (r *Rule) Readings(play []Card) []Reading {
+	var wilds []int
+	for i, card := range play {
+		if r.IsWildCard(card) {
+			wilds = append(wilds, i)
+		}
+	}
+	candidates := r.wildCandidates(play)
+	equivalent := append([]Card{}, play...)
+	seen := make(map[Combination]bool)
+	var readings []Reading
+	var fill func(k int)
+	fill = func(k int) {
+		if k == len(wilds) {
+			combination, err := r.Classify(equivalent)
+			if err == nil && !seen[combination] {
+				seen[combination] = true
+				readings = append(readings, Reading{Combination: combination, Equivalent: append([]Card{}, equivalent...)})
+			}
+			return
+		}
+		for _, card := range candidates {
+			equivalent[wilds[k]] = card
+			fill(k + 1)
+		}
+	}
+	fill(0)
+
+	sort.SliceStable(readings, func(i, j int) bool {
+		a, b := readings[i].Combination, readings[j].Combination
+		if a.Type.IsBomb() != b.Type.IsBomb() {
+			return a.Type.IsBomb()
+		}
+		if a.Type.IsBomb() && r.bombStrength(a) != r.bombStrength(b) {
+			return r.bombStrength(a) > r.bombStrength(b)
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return r.CompareRank(a.Type, a.Rank, b.Rank) > 0
+	})
+	return readings
+}
+
+// wildCandidates returns the cards a wild card of play may stand for. Suits only matter to straight flushes,
+// so the candidates are of every rank but only of the suit of the other cards if they share one, and hearts.
+func (r *Rule) wildCandidates(play []Card) []Card {
+	suits := []Suit{Heart}
+	var suit Suit
+	flush := true
+	for _, card := range play {
+		if r.IsWildCard(card) {
+			continue
+		}
+		if suit == "" {
+			suit = card.Suit
+		}
+		flush = flush && card.Suit == suit
+	}
+	if flush && suit != "" && suit != Heart {
+		suits = append(suits, suit)
+	}
+	var candidates []Card
+	for rank := Two; rank <= Ace; rank++ {
+		for _, s := range suits {
+			candidates = append(candidates, NewCard(s, rank))
+		}
+	}
+	return candidates
+}
@@ -19,28 +19,41 @@ func NewDeck(numDecks int) *Deck {
 	return d
 }
 
+// NewDeckWith creates and returns a new deck of cards shuffled with rng, the same random source dealing the same cards
+func NewDeckWith(numDecks int, rng *rand.Rand) *Deck {
+	d := &Deck{}
+	d.fill(numDecks)
+	d.ShuffleWith(rng)
+	return d
+}
+
 // Initialize creates and returns a new shuffled deck with the specified number of card sets
-// Each set contains 54 cards (52 standard + 2 jokers)
+// Each set contains 54 cards (52 standard + 2 jokers), numbered by the set they come from starting at 1
 func (d *Deck) Initialize(numDecks int) []Card {
+	d.fill(numDecks)
+
+	// Shuffle the deck
+	d.Shuffle()
+
+	return d.cards
+}
+
+// fill puts the cards of numDecks card sets in the deck in order
+func (d *Deck) fill(numDecks int) {
 	d.cards = make([]Card, 0, numDecks*54)
 
 	for i := 0; i < numDecks; i++ {
 		// Add standard cards (2-Ace of each suit)
 		for _, suit := range []Suit{Spade, Heart, Diamond, Club} {
 			for rank := Two; rank <= Ace; rank++ {
-				d.cards = append(d.cards, NewCard(suit, rank))
+				d.cards = append(d.cards, Card{Suit: suit, Rank: rank, Deck: i + 1})
 			}
 		}
 
 		// Add jokers
-		d.cards = append(d.cards, NewCard("", Joker))    // Small Joker
-		d.cards = append(d.cards, NewCard("", BigJoker)) // Big Joker
+		d.cards = append(d.cards, Card{Rank: Joker, Deck: i + 1})    // Small Joker
+		d.cards = append(d.cards, Card{Rank: BigJoker, Deck: i + 1}) // Big Joker
 	}
-
-	// Shuffle the deck
-	d.Shuffle()
-
-	return d.cards
 }
 
 // Split divides the deck into numPlayers equal parts.
@@ -89,6 +102,26 @@ func (d *Deck) Split(numPlayers int) []*Deck {
 	return result
 }
 
+// SplitRevealing divides the deck like Split and records who received the revealed card.
+// It returns the index of the player who received the twin of the revealed card, the copy of the same value
+// from another deck, or the revealed card itself if its twin is not in the deck, and -1 if neither is.
+func (d *Deck) SplitRevealing(numPlayers int, revealed Card) ([]*Deck, int) {
+	decks := d.Split(numPlayers)
+	holder := -1
+	for index, hand := range decks {
+		for _, card := range hand.cards {
+			if !card.SameValue(revealed) {
+				continue
+			}
+			if card.Deck != revealed.Deck {
+				return decks, index
+			}
+			holder = index
+		}
+	}
+	return decks, holder
+}
+
 // Shuffle randomizes the order of cards in the deck
 func (d *Deck) Shuffle() {
 	rand.Seed(time.Now().UnixNano())
@@ -97,6 +130,13 @@ func (d *Deck) Shuffle() {
 	})
 }
 
+// ShuffleWith randomizes the order of cards in the deck with rng
+func (d *Deck) ShuffleWith(rng *rand.Rand) {
+	rng.Shuffle(len(d.cards), func(i, j int) {
+		d.cards[i], d.cards[j] = d.cards[j], d.cards[i]
+	})
+}
+
 // Draw removes and returns the top card from the deck
 func (d *Deck) Draw() (Card, bool) {
 	if len(d.cards) == 0 {
@@ -230,61 +270,31 @@ func (d *Deck) PlayIndexN(indices []int) []Card {
 
 // Play removes the specified card from the deck if it exists.
 // Returns true if the card was found and removed, false otherwise.
+// A card with a deck number removes that physical card, a card without one removes any copy of its value.
 // Note: This performs a linear search through the deck.
 func (d *Deck) Play(card Card) bool {
-	for i, c := range d.cards {
-		if c.Rank == card.Rank && c.Suit == card.Suit {
-			// Remove the card by slicing it out
-			d.cards = append(d.cards[:i], d.cards[i+1:]...)
-			return true
-		}
-	}
-	return false
+	return d.PlayN([]Card{card})
 }
 
 // PlayN removes the specified cards from the deck.
 // Returns true if all cards were found and removed, false otherwise.
 // If any card is not found, no cards are removed (atomic operation).
 func (d *Deck) PlayN(cards []Card) bool {
-	if len(cards) == 0 {
-		return true
+	indexes, ok := d.findN(cards)
+	if !ok {
+		return false
 	}
-
-	// First, check if all cards exist in the deck
-	cardCounts := make(map[Card]int)
-	for _, card := range cards {
-		cardCounts[card]++
+	if len(indexes) == 0 {
+		return true
 	}
 
-	// Verify all cards exist with sufficient quantity
-	tempCounts := make(map[Card]int)
-	for card, needed := range cardCounts {
-		found := 0
-		for _, c := range d.cards {
-			if c.Rank == card.Rank && c.Suit == card.Suit {
-				found++
-				if found == needed {
-					break
-				}
-			}
-		}
-		if found < needed {
-			return false
-		}
-		tempCounts[card] = needed
+	remove := make(map[int]bool, len(indexes))
+	for _, i := range indexes {
+		remove[i] = true
 	}
-
-	// If we got here, all cards exist - now remove them
 	newCards := make([]Card, 0, len(d.cards)-len(cards))
-	removeCounts := make(map[Card]int)
-	for _, card := range cards {
-		removeCounts[card]++
-	}
-
-	for _, card := range d.cards {
-		if count, exists := removeCounts[card]; exists && count > 0 {
-			removeCounts[card]--
-		} else {
+	for i, card := range d.cards {
+		if !remove[i] {
 			newCards = append(newCards, card)
 		}
 	}
@@ -293,6 +303,54 @@ func (d *Deck) PlayN(cards []Card) bool {
 	return true
 }
 
+// HasN returns true if the deck holds all the specified cards, counting duplicates.
+// The deck is not modified.
+func (d *Deck) HasN(cards []Card) bool {
+	_, ok := d.findN(cards)
+	return ok
+}
+
+// FindN returns the physical cards of the deck matching the specified cards, in the same order,
+// and false if the deck does not hold them all. The deck is not modified.
+func (d *Deck) FindN(cards []Card) ([]Card, bool) {
+	indexes, ok := d.findN(cards)
+	if !ok {
+		return nil, false
+	}
+	found := make([]Card, len(indexes))
+	for i, index := range indexes {
+		found[i] = d.cards[index]
+	}
+	return found, true
+}
+
+// findN returns the index in the deck of each of the specified cards, each card of the deck matching once.
+// A card with a deck number only matches that physical card, so those are matched before the cards
+// that match any copy of their value.
+func (d *Deck) findN(cards []Card) ([]int, bool) {
+	indexes := make([]int, len(cards))
+	used := make(map[int]bool, len(cards))
+	for _, physical := range []bool{true, false} {
+		for i, card := range cards {
+			if (card.Deck != 0) != physical {
+				continue
+			}
+			indexes[i] = -1
+			for j, c := range d.cards {
+				if !used[j] && card.matches(c) {
+					indexes[i] = j
+					used[j] = true
+					break
+				}
+			}
+			if indexes[i] < 0 {
+				return nil, false
+			}
+		}
+	}
+	return indexes, true
+}
+
 // MoveNDCards moves multiple cards specified by their indices to the destination index.
 // Returns true if the move was successful, false if any index is out of bounds.
 // The moved cards will maintain their relative order.
@@ -424,53 +482,10 @@ func (d *Deck) GetCards() []Card {
 	return d.cards
 }
 
-// Sort sorts the cards in the deck with the following order:
-// 1. Jokers (Big Joker > Small Joker)
-// 2. Cards matching the trump rank (by suit: Spade > Heart > Club > Diamond)
-// 3. Other cards (by rank, then by suit)
-func (d *Deck) Sort(trumpRank Rank) {
+// Sort sorts the cards in the deck with a sort strategy of the rules, heart wild cards first
+func (d *Deck) Sort(rule RuleAPI, strategy SortStrategy) {
 	if len(d.cards) <= 1 {
 		return
 	}
-
-	// Sort the cards
-	sort.Slice(d.cards, func(i, j int) bool {
-		a, b := d.cards[i], d.cards[j]
-
-		// Jokers first (Big Joker > Small Joker)
-		if a.Rank == BigJoker && b.Rank != BigJoker {
-			return true
-		}
-		if a.Rank != BigJoker && b.Rank == BigJoker {
-			return false
-		}
-		if a.Rank == Joker && b.Rank != Joker {
-			return true
-		}
-		if a.Rank != Joker && b.Rank == Joker {
-			return false
-		}
-
-		// Then cards matching the trump rank
-		aIsTrump := a.Rank == trumpRank
-		bIsTrump := b.Rank == trumpRank
-
-		if aIsTrump && !bIsTrump {
-			return true
-		}
-		if !aIsTrump && bIsTrump {
-			return false
-		}
-		if aIsTrump && bIsTrump {
-			// Both are trump rank, sort by suit
-			return a.Suit > b.Suit
-		}
-
-		// For non-trump cards, sort by rank then by suit
-		if a.Rank != b.Rank {
-			return a.Rank < b.Rank
-		}
-		suitOrder := map[Suit]int{Spade: 4, Heart: 3, Club: 2, Diamond: 1}
-		return suitOrder[a.Suit] > suitOrder[b.Suit]
-	})
+	rule.SortCards(d.cards, strategy)
 }
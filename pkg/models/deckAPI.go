@@ -8,6 +8,10 @@ type DeckAPI interface {
 	// Split splits the deck into numPlayers equal parts
 	Split(numPlayers int) []*Deck
 
+	// SplitRevealing splits the deck like Split and returns the index of the player who received
+	// the twin of the revealed card, or the revealed card itself if it has no twin in the deck
+	SplitRevealing(numPlayers int, revealed Card) ([]*Deck, int)
+
 	// GetCards returns a copy of all cards in the deck
 	GetCards() []Card
 
@@ -29,6 +33,12 @@ type DeckAPI interface {
 	// PlayN removes the specified cards from the deck
 	PlayN(cards []Card) bool
 
+	// HasN returns true if the deck holds all the specified cards
+	HasN(cards []Card) bool
+
+	// FindN returns the physical cards of the deck matching the specified cards
+	FindN(cards []Card) ([]Card, bool)
+
 	// PlayIndex removes and returns the card at the specified index
 	PlayIndex(index int) (Card, bool)
 
@@ -47,10 +57,8 @@ type DeckAPI interface {
 	// If the destination is within the source range, returns false as this would create an invalid state.
 	MoveNDCards(srcIndexes []int, dest int) bool
 
-	// Sort sorts the cards in the deck with jokers first, then cards matching the trump rank,
-	// then other cards by rank
-	// todo: use the sort algorithm provided by Rule
-	Sort(trumpRank Rank)
+	// Sort sorts the cards in the deck with a sort strategy of the rules, heart wild cards first
+	Sort(rule RuleAPI, strategy SortStrategy)
 
 	// String returns a string representation of all cards in the deck
 	String() string
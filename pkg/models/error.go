@@ -0,0 +1,60 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ErrorCode is a machine-readable reason for the server refusing a client message
+type ErrorCode string
+
+// Constants for error codes
+const (
+	CodeNotYourTurn        ErrorCode = "notYourTurn"
+	CodeCardsNotInHand     ErrorCode = "cardsNotInHand"
+	CodeIllegalCombination ErrorCode = "illegalCombination"
+	CodeDoesNotBeatTable   ErrorCode = "doesNotBeatTable"
+	CodeWrongPhase         ErrorCode = "wrongPhase"
+	CodeMalformed          ErrorCode = "malformed"
+	CodeSeatTaken          ErrorCode = "seatTaken"
+	CodeNotSeated          ErrorCode = "notSeated"
+	CodeIllegalTribute     ErrorCode = "illegalTribute"
+)
+
+// ProtocolError is an error sent from server to client with the "error" and "invalidPlay" actions
+// Code is meant for programs, Message is meant for the player
+type ProtocolError struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+}
+
+// NewProtocolError creates a new protocol error with a formatted message
+func NewProtocolError(code ErrorCode, format string, args ...interface{}) *ProtocolError {
+	return &ProtocolError{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// Error returns the human readable message of the error
+func (e *ProtocolError) Error() string {
+	return e.Message
+}
+
+// ConstructErrorServerMessage constructs the data of an error server message from the given error
+func ConstructErrorServerMessage(e *ProtocolError) string {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return e.Message
+	}
+	return string(data)
+}
+
+// ParseErrorServerMessage parses the data of an error server message
+func ParseErrorServerMessage(msg string) (*ProtocolError, error) {
+	var e ProtocolError
+	if err := json.Unmarshal([]byte(msg), &e); err != nil {
+		return nil, fmt.Errorf("failed to parse error message: %w", err)
+	}
+	return &e, nil
+}
@@ -1,13 +1,21 @@
 package models
 
+import (
+	"fmt"
+	"sync"
+)
+
 // Info is a placeholder struct for game information
+// Info is safe for concurrent use, its getters return copies and never the internal maps and slices
 type Info struct {
+	// mu protects all fields below
+	mu sync.RWMutex
 	// numPlayers is the number of players in the game
 	numPlayers int
-	// grp1Name is the name of group 1
-	grp1Name string
-	// grp2Name is the name of group 2
-	grp2Name string
+	// table is the configuration of the table, its size, cards and teams
+	table TableConfig
+	// teams lists the teams at the table with their names, members and levels
+	teams []Team
 	// readyToStart is a map of player indexes to their ready status
 	readyToStart map[int]bool
 	// readyToPlay is a map of player indexes to their ready to play status
@@ -40,146 +48,439 @@ type Info struct {
 	names map[int]string
 	// finishedIndexes is a list of indexes of players who have finished a round
 	finishedIndexes []int
+	// phase is the current phase of the game
+	phase Phase
+	// passedIndexes is a list of indexes of players who passed since the last play
+	passedIndexes []int
+	// away is a map of player indexes to their away status, an away player lost the connection mid-round
+	away map[int]bool
+	// leavePolicy decides what happens when a seated player leaves mid-round
+	leavePolicy LeavePolicy
+}
+
+// LeavePolicy decides what happens when a seated player leaves during a round
+type LeavePolicy string
+
+// Constants for leave policies
+const (
+	LeaveWait    LeavePolicy = "wait"    // pause on the player's turn until they come back
+	LeaveBot     LeavePolicy = "bot"     // the server plays for the player until they come back
+	LeaveForfeit LeavePolicy = "forfeit" // the player's team forfeits the round
+)
+
+// ParseLeavePolicy converts a string to a LeavePolicy
+func ParseLeavePolicy(s string) (LeavePolicy, error) {
+	switch policy := LeavePolicy(s); policy {
+	case LeaveWait, LeaveBot, LeaveForfeit:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid leave policy: %s", s)
+	}
+}
+
+// GetLeavePolicy returns the leave policy of the table
+// Returns LeaveWait if no policy has been set
+func (i *Info) GetLeavePolicy() LeavePolicy {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	if i.leavePolicy == "" {
+		return LeaveWait
+	}
+	return i.leavePolicy
+}
+
+// SetLeavePolicy sets the leave policy of the table
+func (i *Info) SetLeavePolicy(policy LeavePolicy) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.leavePolicy = policy
+}
+
+// Phase represents the phase of the game at the table
+type Phase string
+
+// Constants for game phases
+const (
+	PhaseLobby     Phase = "lobby"     // players are joining and getting ready
+	PhaseDealing   Phase = "dealing"   // cards are being dealt
+	PhaseTribute   Phase = "tribute"   // the losers of the last round pay tribute and get a card back
+	PhaseArranging Phase = "arranging" // cards are dealt and players arrange their hands
+	PhasePlaying   Phase = "playing"   // the round is being played
+	PhaseRoundOver Phase = "roundOver" // the round is over and players get ready for the next one
+	PhaseMatchOver Phase = "matchOver" // a team won the match by winning at level A
+)
+
+// GetPhase returns the current phase of the game
+// Returns PhaseLobby if no phase has been set
+func (i *Info) GetPhase() Phase {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	if i.phase == "" {
+		return PhaseLobby
+	}
+	return i.phase
+}
+
+// SetPhase sets the current phase of the game
+func (i *Info) SetPhase(phase Phase) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.phase = phase
+}
+
+// GetGrpLevels returns the levels of both groups
+// A group that has no level yet is at level Two
+func (i *Info) GetGrpLevels() [2]Rank {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	var levels [2]Rank
+	for g, team := range i.getTeams() {
+		if g < len(levels) {
+			levels[g] = team.Level
+		}
+	}
+	return levels
+}
+
+// SetGrpLevels sets the levels of both groups
+func (i *Info) SetGrpLevels(levels [2]Rank) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.initTeams()
+	for g := range levels {
+		if g < len(i.teams) {
+			i.teams[g].Level = levels[g]
+		}
+	}
+}
+
+// GetTeams returns a copy of the teams at the table
+// Returns the default teams if none have been set
+func (i *Info) GetTeams() []Team {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return copyTeams(i.getTeams())
+}
+
+// SetTeams sets the teams at the table to a copy of teams
+func (i *Info) SetTeams(teams []Team) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.teams = copyTeams(teams)
+}
+
+// GetTeamOf returns the team of the seat at index
+func (i *Info) GetTeamOf(index int) int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	for t, team := range i.getTeams() {
+		if team.HasMember(index) {
+			return t
+		}
+	}
+	return TeamOf(index)
+}
+
+// getTeams returns the teams at the table, or the default teams if none have been set.
+// The caller must hold the lock and must not modify the result.
+func (i *Info) getTeams() []Team {
+	if i.teams == nil {
+		return NewTeams(i.numPlayers)
+	}
+	return i.teams
+}
+
+// initTeams sets the default teams if none have been set, the caller must hold the write lock
+func (i *Info) initTeams() {
+	if i.teams == nil {
+		i.teams = NewTeams(i.numPlayers)
+	}
+}
+
+// GetPassedIndexes returns a copy of the list of player indexes who passed since the last play
+func (i *Info) GetPassedIndexes() []int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return append([]int{}, i.passedIndexes...)
+}
+
+// AddPassedIndex records that the player at index passed
+func (i *Info) AddPassedIndex(index int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.passedIndexes = append(i.passedIndexes, index)
+}
+
+// ResetPassedIndexes clears the list of passed player indexes
+func (i *Info) ResetPassedIndexes() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.passedIndexes = nil
 }
 
 // GetLastPlayedIndex returns the index of the last player to play
 func (i *Info) GetLastPlayedIndex() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
 	return i.lastPlayedIndex
 }
 
 // SetLastPlayedIndex sets the index of the last player to play
 func (i *Info) SetLastPlayedIndex(index int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
 	i.lastPlayedIndex = index
 }
 
-// GetFinishedIndexes returns the list of player indexes who have finished the round
+// GetFinishedIndexes returns a copy of the list of player indexes who have finished the round
 func (i *Info) GetFinishedIndexes() []int {
-	if i.finishedIndexes == nil {
-		i.finishedIndexes = make([]int, 0)
-	}
-	return i.finishedIndexes
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return append([]int{}, i.finishedIndexes...)
 }
 
-// SetFinishedIndexes sets the list of player indexes who have finished the round
+// SetFinishedIndexes sets the list of player indexes who have finished the round to a copy of indexes
 func (i *Info) SetFinishedIndexes(indexes []int) {
-	i.finishedIndexes = indexes
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.finishedIndexes = append([]int{}, indexes...)
 }
 
 // ResetFinishedIndexes clears the list of finished player indexes
 func (i *Info) ResetFinishedIndexes() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
 	i.finishedIndexes = nil
 }
 
-// GetReadyToStartMap returns a reference to the readyToStart map
+// GetReadyToStartMap returns a copy of the readyToStart map
 func (i *Info) GetReadyToStartMap() map[int]bool {
-	if i.readyToStart == nil {
-		i.readyToStart = make(map[int]bool)
-	}
-	return i.readyToStart
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return copyMap(i.readyToStart)
 }
 
-// SetReadyToStartMap sets the readyToStart map reference
+// SetReadyToStartMap sets the readyToStart map to a copy of ready
 func (i *Info) SetReadyToStartMap(ready map[int]bool) {
-	i.readyToStart = ready
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.readyToStart = copyMap(ready)
 }
 
-// GetAvailableSlots returns a reference to the availableSlots map
-func (i *Info) GetAvailableSlots() map[int]bool {
-	if i.availableSlots == nil {
-		i.availableSlots = make(map[int]bool)
+// MarkReady sets a player's ready to start status to true and reports whether every player is now ready,
+// in which case the readyToStart map is cleared for the next round
+func (i *Info) MarkReady(index int) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.readyToStart == nil {
+		i.readyToStart = make(map[int]bool)
+	}
+	i.readyToStart[index] = true
+	if len(i.readyToStart) < i.numPlayers {
+		return false
 	}
-	return i.availableSlots
+	i.readyToStart = make(map[int]bool)
+	return true
+}
+
+// GetAvailableSlots returns a copy of the availableSlots map
+func (i *Info) GetAvailableSlots() map[int]bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return copyMap(i.availableSlots)
 }
 
-// SetAvailableSlots sets the availableSlots map reference
+// SetAvailableSlots sets the availableSlots map to a copy of slots
 func (i *Info) SetAvailableSlots(slots map[int]bool) {
-	i.availableSlots = slots
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.availableSlots = copyMap(slots)
 }
 
-// GetNames returns a reference to the names map
+// GetNames returns a copy of the names map
 func (i *Info) GetNames() map[int]string {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return copyMap(i.names)
+}
+
+// SetNames sets the names map to a copy of names
+func (i *Info) SetNames(names map[int]string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.names = copyMap(names)
+}
+
+// GetName returns the name of the player seated at index, and false if the seat is available
+func (i *Info) GetName(index int) (string, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	name, ok := i.names[index]
+	return name, ok
+}
+
+// TakeSeat seats the player under name at index if the slot is available, and reports whether it was
+func (i *Info) TakeSeat(index int, name string) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if !i.availableSlots[index] {
+		return false
+	}
+	delete(i.availableSlots, index)
 	if i.names == nil {
 		i.names = make(map[int]string)
 	}
-	return i.names
+	i.names[index] = name
+	return true
+}
+
+// SwapSeats moves the players seated at a and b to each other's seat,
+// a seat can be available in which case the player moves to it
+func (i *Info) SwapSeats(a int, b int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	swapEntries(i.names, a, b)
+	swapEntries(i.availableSlots, a, b)
+	swapEntries(i.readyToStart, a, b)
+	swapEntries(i.readyToPlay, a, b)
+	swapEntries(i.away, a, b)
+}
+
+// FreeSeat makes the slot at index available again and forgets everything about its player
+func (i *Info) FreeSeat(index int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.availableSlots == nil {
+		i.availableSlots = make(map[int]bool)
+	}
+	i.availableSlots[index] = true
+	delete(i.names, index)
+	delete(i.readyToStart, index)
+	delete(i.readyToPlay, index)
+	delete(i.away, index)
 }
 
-// SetNames sets the names map reference
-func (i *Info) SetNames(names map[int]string) {
-	i.names = names
+// GetTable returns a copy of the configuration of the table
+func (i *Info) GetTable() TableConfig {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return copyTable(i.table)
+}
+
+// SetTable sets the configuration of the table, with its number of players and its teams at level Two
+func (i *Info) SetTable(table TableConfig) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.table = copyTable(table)
+	i.numPlayers = table.NumPlayers
+	i.teams = table.NewTeams()
 }
 
 // GetNumPlayers returns the number of players in the game
 func (i *Info) GetNumPlayers() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
 	return i.numPlayers
 }
 
 // SetNumPlayers sets the number of players in the game
 func (i *Info) SetNumPlayers(numPlayers int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
 	i.numPlayers = numPlayers
+	i.initTeams()
 }
 
 // GetGrp1Name returns the name of group 1
 // Returns "Group1" if no name has been set
 func (i *Info) GetGrp1Name() string {
-	if i.grp1Name == "" {
-		return "Group1"
-	}
-	return i.grp1Name
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.getTeams()[0].Name
 }
 
 // SetGrp1Name sets the name of group 1
 func (i *Info) SetGrp1Name(name string) {
-	i.grp1Name = name
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.initTeams()
+	i.teams[0].Name = name
 }
 
 // GetGrp2Name returns the name of group 2
 // Returns "Group2" if no name has been set
 func (i *Info) GetGrp2Name() string {
-	if i.grp2Name == "" {
-		return "Group2"
-	}
-	return i.grp2Name
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.getTeams()[1].Name
 }
 
 // SetGrp2Name sets the name of group 2
 func (i *Info) SetGrp2Name(name string) {
-	i.grp2Name = name
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.initTeams()
+	i.teams[1].Name = name
 }
 
-// SetReadyToPlay sets the map of player indexes to their ready to play status
+// SetReadyToPlay sets the map of player indexes to their ready to play status to a copy of readyMap
 func (i *Info) SetReadyToPlay(readyMap map[int]bool) {
-	i.readyToPlay = readyMap
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.readyToPlay = copyMap(readyMap)
 }
 
-// GetReadyToPlay returns the map of player indexes to their ready to play status
+// GetReadyToPlay returns a copy of the map of player indexes to their ready to play status
 func (i *Info) GetReadyToPlay() map[int]bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return copyMap(i.readyToPlay)
+}
+
+// AddReadyToPlay sets a player's ready to play status to true
+func (i *Info) AddReadyToPlay(index int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
 	if i.readyToPlay == nil {
 		i.readyToPlay = make(map[int]bool)
 	}
-	return i.readyToPlay
+	i.readyToPlay[index] = true
 }
 
-// AddReadyToPlay sets a player's ready to play status to true
-func (i *Info) AddReadyToPlay(index int) {
+// MarkReadyToPlay sets a player's ready to play status to true and reports whether every player is now ready,
+// in which case the ready to play map is cleared for the next round
+func (i *Info) MarkReadyToPlay(index int) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
 	if i.readyToPlay == nil {
 		i.readyToPlay = make(map[int]bool)
 	}
 	i.readyToPlay[index] = true
+	if len(i.readyToPlay) < i.numPlayers {
+		return false
+	}
+	i.readyToPlay = make(map[int]bool)
+	return true
 }
 
 // RemoveReadyToPlay removes a player's ready to play status
 func (i *Info) RemoveReadyToPlay(index int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
 	delete(i.readyToPlay, index)
 }
 
 // IsReadyToPlay checks if a player is ready to play
 func (i *Info) IsReadyToPlay(index int) bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
 	return i.readyToPlay != nil && i.readyToPlay[index]
 }
 
 // AllPlayersReadyToPlay checks if all players are ready to play
 func (i *Info) AllPlayersReadyToPlay() bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
 	if i.readyToPlay == nil {
 		return false
 	}
@@ -188,102 +489,265 @@ func (i *Info) AllPlayersReadyToPlay() bool {
 
 // GetIsFirstRound returns whether it's the first round of the game
 func (i *Info) GetIsFirstRound() bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
 	return i.isFirstRound
 }
 
 // SetIsFirstRound sets whether it's the first round of the game
 func (i *Info) SetIsFirstRound(isFirstRound bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
 	i.isFirstRound = isFirstRound
 }
 
 // GetIsRoundInSession returns whether a round is currently in session
 func (i *Info) GetIsRoundInSession() bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
 	return i.isRoundInSession
 }
 
 // SetIsRoundInSession sets whether a round is currently in session
 func (i *Info) SetIsRoundInSession(isRoundInSession bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
 	i.isRoundInSession = isRoundInSession
 }
 
 // GetCurrentPlayerIndex returns the index of the current player
 func (i *Info) GetCurrentPlayerIndex() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
 	return i.currentPlayerIndex
 }
 
 // SetCurrentPlayerIndex sets the index of the current player
 func (i *Info) SetCurrentPlayerIndex(index int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
 	i.currentPlayerIndex = index
 }
 
 // GetTrumpRank returns the trump rank for the current round
 func (i *Info) GetTrumpRank() Rank {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
 	return i.trumpRank
 }
 
 // SetTrumpRank sets the trump rank for the current round
 func (i *Info) SetTrumpRank(rank Rank) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
 	i.trumpRank = rank
 }
 
 // GetGrpScores returns the scores of both groups
 func (i *Info) GetGrpScores() [2]int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
 	return i.grpScores
 }
 
 // SetGrpScores sets the scores of both groups
 func (i *Info) SetGrpScores(scores [2]int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
 	i.grpScores = scores
 }
 
 // GetFirstFinishedIndex returns the index of the first player to finish a round
 func (i *Info) GetFirstFinishedIndex() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
 	return i.firstFinishedIndex
 }
 
 // SetFirstFinishedIndex sets the index of the first player to finish a round
 func (i *Info) SetFirstFinishedIndex(index int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
 	i.firstFinishedIndex = index
 }
 
 // GetSecondFinishedIndex returns the index of the second player to finish a round
 func (i *Info) GetSecondFinishedIndex() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
 	return i.secondFinishedIndex
 }
 
 // SetSecondFinishedIndex sets the index of the second player to finish a round
 func (i *Info) SetSecondFinishedIndex(index int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
 	i.secondFinishedIndex = index
 }
 
 // GetLastFinishedIndex returns the index of the last player to finish a round
 func (i *Info) GetLastFinishedIndex() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
 	return i.lastFinishedIndex
 }
 
 // SetLastFinishedIndex sets the index of the last player to finish a round
 func (i *Info) SetLastFinishedIndex(index int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
 	i.lastFinishedIndex = index
 }
 
 // GetSecondToLastFinishedIndex returns the index of the second to last player to finish a round
 func (i *Info) GetSecondToLastFinishedIndex() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
 	return i.secondToLastFinishedIndex
 }
 
 // SetSecondToLastFinishedIndex sets the index of the second to last player to finish a round
 func (i *Info) SetSecondToLastFinishedIndex(index int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
 	i.secondToLastFinishedIndex = index
 }
 
-// GetLastPlayedCards returns the last played cards
-// Note: Returns the actual slice, not a copy
+// GetLastPlayedCards returns a copy of the last played cards, nil if the next player leads
 func (i *Info) GetLastPlayedCards() []Card {
-	return i.lastPlayedCards
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return copyCards(i.lastPlayedCards)
 }
 
-// SetLastPlayedCards sets the last played cards
-// Note: Stores the provided slice directly, no copying is done
+// SetLastPlayedCards sets the last played cards to a copy of cards, nil clears the table
 func (i *Info) SetLastPlayedCards(cards []Card) {
-	i.lastPlayedCards = cards
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.lastPlayedCards = copyCards(cards)
+}
+
+// RecordPlay puts the cards played by the player at index on top of the table
+// and clears the players who passed on the previous play
+func (i *Info) RecordPlay(index int, cards []Card) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.lastPlayedCards = copyCards(cards)
+	i.lastPlayedIndex = index
+	i.passedIndexes = nil
+}
+
+// SetAway sets whether the player at index is away
+func (i *Info) SetAway(index int, away bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.away == nil {
+		i.away = make(map[int]bool)
+	}
+	if away {
+		i.away[index] = true
+	} else {
+		delete(i.away, index)
+	}
+}
+
+// IsAway checks if the player at index is away
+func (i *Info) IsAway(index int) bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.away != nil && i.away[index]
+}
+
+// InfoSnapshot is a copy of the game information taken at one moment,
+// it shares nothing with the Info it was taken from
+type InfoSnapshot struct {
+	NumPlayers         int
+	Grp1Name           string
+	Grp2Name           string
+	Phase              Phase
+	IsRoundInSession   bool
+	CurrentPlayerIndex int
+	TrumpRank          Rank
+	GrpLevels          [2]Rank
+	Teams              []Team
+	LastPlayedCards    []Card
+	LastPlayedIndex    int
+	PassedIndexes      []int
+	FinishedIndexes    []int
+	Names              map[int]string
+	AvailableSlots     map[int]bool
+	ReadyToStart       map[int]bool
+	ReadyToPlay        map[int]bool
+	Away               map[int]bool
+	LeavePolicy        LeavePolicy
+}
+
+// Snapshot returns a consistent copy of the game information for readers
+func (i *Info) Snapshot() InfoSnapshot {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	s := InfoSnapshot{
+		NumPlayers:         i.numPlayers,
+		Phase:              i.phase,
+		IsRoundInSession:   i.isRoundInSession,
+		CurrentPlayerIndex: i.currentPlayerIndex,
+		TrumpRank:          i.trumpRank,
+		Teams:              copyTeams(i.getTeams()),
+		LastPlayedCards:    copyCards(i.lastPlayedCards),
+		LastPlayedIndex:    i.lastPlayedIndex,
+		PassedIndexes:      append([]int{}, i.passedIndexes...),
+		FinishedIndexes:    append([]int{}, i.finishedIndexes...),
+		Names:              copyMap(i.names),
+		AvailableSlots:     copyMap(i.availableSlots),
+		ReadyToStart:       copyMap(i.readyToStart),
+		ReadyToPlay:        copyMap(i.readyToPlay),
+		Away:               copyMap(i.away),
+		LeavePolicy:        i.leavePolicy,
+	}
+	if len(s.Teams) >= 2 {
+		s.Grp1Name, s.Grp2Name = s.Teams[0].Name, s.Teams[1].Name
+	}
+	if s.Phase == "" {
+		s.Phase = PhaseLobby
+	}
+	for g := range s.GrpLevels {
+		if g < len(s.Teams) {
+			s.GrpLevels[g] = s.Teams[g].Level
+		}
+	}
+	if s.LeavePolicy == "" {
+		s.LeavePolicy = LeaveWait
+	}
+	return s
+}
+
+// copyMap returns a copy of m, never nil
+func copyMap[K comparable, V any](m map[K]V) map[K]V {
+	c := make(map[K]V, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// swapEntries swaps the entries of m at a and b, an entry missing at one key goes missing at the other
+func swapEntries[V any](m map[int]V, a int, b int) {
+	va, okA := m[a]
+	vb, okB := m[b]
+	delete(m, a)
+	delete(m, b)
+	if okA {
+		m[b] = va
+	}
+	if okB {
+		m[a] = vb
+	}
+}
+
+// copyCards returns a copy of cards, nil if cards is nil
+func copyCards(cards []Card) []Card {
+	if cards == nil {
+		return nil
+	}
+	return append([]Card{}, cards...)
 }
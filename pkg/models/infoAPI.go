@@ -2,6 +2,10 @@ package models
 
 // InfoAPI defines the interface for accessing and modifying game information
 type InfoAPI interface {
+	// Table configuration
+	GetTable() TableConfig
+	SetTable(table TableConfig)
+
 	// Player counts and indexes
 	GetNumPlayers() int
 	SetNumPlayers(numPlayers int)
@@ -12,6 +16,12 @@ type InfoAPI interface {
 	GetGrp2Name() string
 	SetGrp2Name(name string)
 
+	// Teams and seats
+	GetTeams() []Team
+	SetTeams(teams []Team)
+	GetTeamOf(index int) int
+	SwapSeats(a int, b int)
+
 	// Ready states
 	GetReadyToStartMap() map[int]bool
 	SetReadyToStartMap(ready map[int]bool)
@@ -25,6 +35,11 @@ type InfoAPI interface {
 	SetAvailableSlots(slots map[int]bool)
 	GetNames() map[int]string
 	SetNames(names map[int]string)
+	GetName(index int) (string, bool)
+	TakeSeat(index int, name string) bool
+	FreeSeat(index int)
+	MarkReady(index int) bool
+	MarkReadyToPlay(index int) bool
 	GetLastPlayedIndex() int
 	SetLastPlayedIndex(index int)
 	GetFinishedIndexes() []int
@@ -62,6 +77,31 @@ type InfoAPI interface {
 	// Last played cards
 	GetLastPlayedCards() []Card
 	SetLastPlayedCards(cards []Card)
+	RecordPlay(index int, cards []Card)
+
+	// Game phase
+	GetPhase() Phase
+	SetPhase(phase Phase)
+
+	// Group levels
+	GetGrpLevels() [2]Rank
+	SetGrpLevels(levels [2]Rank)
+
+	// Players who passed since the last play
+	GetPassedIndexes() []int
+	AddPassedIndex(index int)
+	ResetPassedIndexes()
+
+	// Players who lost the connection mid-round
+	SetAway(index int, away bool)
+	IsAway(index int) bool
+
+	// Leave policy
+	GetLeavePolicy() LeavePolicy
+	SetLeavePolicy(policy LeavePolicy)
+
+	// Consistent copy for readers
+	Snapshot() InfoSnapshot
 }
 
 // Verify at compile time that *Info implements InfoAPI
@@ -8,59 +8,141 @@ import (
 	"strings"
 )
 
-// action can be ["join", "ready", "start", "tribute", "return", "playAttempt", "play", "pass", "leave"]
+// action can be ["join", "ready", "start", "team", "swap", "tribute", "return", "play", "pass", "leave", "state"]
+// team carries the index of the team to move to and swap the index of the seat to move to, both before the match starts
+// DryRun asks the server to only validate a "play" without applying it
+// Token is the seat token from joinConfirm, a "join" with it takes the seat back after a disconnect or a restart
 type ClientMessage struct {
 	Index  int    `json:"index"`
 	Action string `json:"action"`
 	Data   string `json:"data"`
+	DryRun bool   `json:"dryRun,omitempty"`
+	Token  string `json:"token,omitempty"`
 }
 
 // ServerMessage represents a message sent from server to client
-// action can be ["availableSlots", "joinConfirm", "allJoined", "startRound", "play", "validPlay", "invalidPlay", "lastPlay"]
+// action can be ["availableSlots", "joinConfirm", "allJoined", "startRound", "tributeDue", "antiTribute", "tributePaid",
+// "cardReturned", "arrange", "play", "validPlay", "invalidPlay", "lastPlay", "passed", "finished", "roundOver", "matchOver",
+// "state", "away", "back", "leave", "swapRequested", "seatsSwapped", "rules", "error"]
+// Every message about a player carries the player's team after the player's index
+// joinConfirm carries the seat token and the team of the seat, and is followed by rules with the house rules of the table
+// validPlay is only sent in reply to a dry run play
+// invalidPlay and error carry a ProtocolError encoded with ConstructErrorServerMessage
 type ServerMessage struct {
 	Action string `json:"action"`
 	Data   string `json:"data"`
 }
 
-func ParseLastPlayServerMessage(msg string) (int, int, DeckAPI, DeckAPI, error) {
+// ConstructPlayerServerMessage constructs the data of a server message about one player
+// from the index of the player, the player's team and the other fields of the message
+func ConstructPlayerServerMessage(playerIndex int, team int, fields ...string) string {
+	parts := append([]string{strconv.Itoa(playerIndex), strconv.Itoa(team)}, fields...)
+	return strings.Join(parts, ";")
+}
+
+// ParsePlayerServerMessage parses the data of a server message about one player
+// into the index of the player, the player's team and the other fields of the message
+func ParsePlayerServerMessage(msg string) (int, int, []string, error) {
+	parts := strings.Split(msg, ";")
+	if len(parts) < 2 {
+		return 0, 0, nil, fmt.Errorf("invalid message format: expected a player index and a team separated by ';'")
+	}
+	playerIndex, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("invalid player index: %v", err)
+	}
+	team, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("invalid team: %v", err)
+	}
+	return playerIndex, team, parts[2:], nil
+}
+
+// ConstructAvailableSlotsServerMessage constructs an available slots message string from the slots
+// and the team of each slot, as space-separated slot:team pairs
+func ConstructAvailableSlotsServerMessage(slots []int, teams []int) string {
+	pairs := make([]string, len(slots))
+	for i, slot := range slots {
+		pairs[i] = fmt.Sprintf("%d:%d", slot, teams[i])
+	}
+	return strings.Join(pairs, " ")
+}
+
+// ParseAvailableSlotsServerMessage parses an available slots message string into the slots and the team of each slot,
+// a slot without a team is in its default team
+func ParseAvailableSlotsServerMessage(msg string) ([]int, []int, error) {
+	var slots, teams []int
+	for _, pair := range strings.Fields(msg) {
+		slotStr, teamStr, hasTeam := strings.Cut(pair, ":")
+		slot, err := strconv.Atoi(slotStr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid slot '%s': %v", slotStr, err)
+		}
+		team := TeamOf(slot)
+		if hasTeam {
+			if team, err = strconv.Atoi(teamStr); err != nil {
+				return nil, nil, fmt.Errorf("invalid team '%s': %v", teamStr, err)
+			}
+		}
+		slots = append(slots, slot)
+		teams = append(teams, team)
+	}
+	return slots, teams, nil
+}
+
+// ConstructLastPlayServerMessage constructs a last play message string from the index of the player, the player's team,
+// the number of cards the player has left, the cards played and their equivalent
+func ConstructLastPlayServerMessage(playerIndex int, team int, numCardsLeft int, attempt []Card, equivalent []Card) string {
+	return fmt.Sprintf("%d;%d;%d;%s;%s", playerIndex, team, numCardsLeft, CardsString(attempt), CardsString(equivalent))
+}
+
+// ParseLastPlayServerMessage parses a last play message string into its components
+func ParseLastPlayServerMessage(msg string) (int, int, int, DeckAPI, DeckAPI, error) {
 	// Split the message by semicolon
-	parts := strings.SplitN(msg, ";", 4)
-	if len(parts) != 4 {
-		return 0, 0, nil, nil, fmt.Errorf("invalid message format: expected 4 parts separated by ';'")
+	parts := strings.SplitN(msg, ";", 5)
+	if len(parts) != 5 {
+		return 0, 0, 0, nil, nil, fmt.Errorf("invalid message format: expected 5 parts separated by ';'")
 	}
 
 	// Parse player index
 	playerIndex, err := strconv.Atoi(parts[0])
 	if err != nil {
-		return 0, 0, nil, nil, fmt.Errorf("invalid player index: %v", err)
+		return 0, 0, 0, nil, nil, fmt.Errorf("invalid player index: %v", err)
+	}
+
+	// Parse team
+	team, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, 0, nil, nil, fmt.Errorf("invalid team: %v", err)
 	}
 
 	// Parse numCardsLeft
-	numCardsLeft, err := strconv.Atoi(parts[1])
+	numCardsLeft, err := strconv.Atoi(parts[2])
 	if err != nil {
-		return 0, 0, nil, nil, fmt.Errorf("invalid number of cards left: %v", err)
+		return 0, 0, 0, nil, nil, fmt.Errorf("invalid number of cards left: %v", err)
 	}
 
 	// Parse attempt cards
-	attemptDeck, err := NewDeckFromString(parts[2])
+	attemptDeck, err := NewDeckFromString(parts[3])
 	if err != nil {
-		return 0, 0, nil, nil, fmt.Errorf("failed to parse attempt cards: %v", err)
+		return 0, 0, 0, nil, nil, fmt.Errorf("failed to parse attempt cards: %v", err)
 	}
 
 	// Parse equivalent cards
-	equivalentDeck, err := NewDeckFromString(parts[3])
+	equivalentDeck, err := NewDeckFromString(parts[4])
 	if err != nil {
-		return 0, 0, nil, nil, fmt.Errorf("failed to parse equivalent cards: %v", err)
+		return 0, 0, 0, nil, nil, fmt.Errorf("failed to parse equivalent cards: %v", err)
 	}
 
-	return playerIndex, numCardsLeft, attemptDeck, equivalentDeck, nil
+	return playerIndex, team, numCardsLeft, attemptDeck, equivalentDeck, nil
 }
 
-// ConstructStartRoundServerMessage constructs a start round message string from the given deck and info
-// deck is the deck of cards
-// info is the game info
-func ConstructStartRoundServerMessage(deck DeckAPI, info InfoAPI) string {
-	return CardsString(deck.GetCards()) + ";" + RankToString(info.GetTrumpRank()) + ";" + strings.Trim(fmt.Sprint(info.GetFinishedIndexes()), "[]")
+// ConstructStartRoundServerMessage constructs a start round message string
+// cards is the hand dealt to the player
+// trumpRank is the trump rank of the round
+// finishedIndexes is the finishing order of the last round
+func ConstructStartRoundServerMessage(cards []Card, trumpRank Rank, finishedIndexes []int) string {
+	return CardsString(cards) + ";" + RankToString(trumpRank) + ";" + strings.Trim(fmt.Sprint(finishedIndexes), "[]")
 }
 
 func ParseStartRoundServerMessage(msg string) (*Deck, Rank, []int, error) {
@@ -81,15 +163,15 @@ func ParseStartRoundServerMessage(msg string) (*Deck, Rank, []int, error) {
 	}
 
 	// Parse trump rank
-	trumpRank, err := StringToRank(trumpRankStr)
+	trumpRank, err := ParseRank(trumpRankStr)
 	if err != nil {
 		return nil, 0, nil, fmt.Errorf("failed to parse trump rank: %v", err)
 	}
 
-	// Parse finished indexes (comma-separated integers)
+	// Parse finished indexes (integers separated by commas or spaces)
 	var finishedIndexes []int
 	if finishedIndexesStr != "" {
-		indexStrs := strings.Split(finishedIndexesStr, ",")
+		indexStrs := strings.FieldsFunc(finishedIndexesStr, func(r rune) bool { return r == ',' || r == ' ' })
 		for _, idxStr := range indexStrs {
 			idx, err := strconv.Atoi(strings.TrimSpace(idxStr))
 			if err != nil {
@@ -102,43 +184,152 @@ func ParseStartRoundServerMessage(msg string) (*Deck, Rank, []int, error) {
 	return deck, trumpRank, finishedIndexes, nil
 }
 
+// ConstructRoundOverServerMessage constructs a round over message string from the finishing order,
+// the winning team, the number of levels it went up and the new levels of every team
+func ConstructRoundOverServerMessage(finishedIndexes []int, team int, levelsUp int, levels []Rank) string {
+	levelStrs := make([]string, len(levels))
+	for t, level := range levels {
+		levelStrs[t] = RankToString(level)
+	}
+	return fmt.Sprintf("%s;%d;%d;%s", strings.Trim(fmt.Sprint(finishedIndexes), "[]"), team, levelsUp, strings.Join(levelStrs, ","))
+}
+
+// ParseRoundOverServerMessage parses a round over message string into its components
+func ParseRoundOverServerMessage(msg string) ([]int, int, int, []Rank, error) {
+	var levels []Rank
+	parts := strings.SplitN(msg, ";", 4)
+	if len(parts) != 4 {
+		return nil, 0, 0, levels, fmt.Errorf("invalid message format: expected 4 parts separated by ';'")
+	}
+
+	var finishedIndexes []int
+	for _, idxStr := range strings.Fields(parts[0]) {
+		idx, err := strconv.Atoi(idxStr)
+		if err != nil {
+			return nil, 0, 0, levels, fmt.Errorf("failed to parse finished index '%s': %v", idxStr, err)
+		}
+		finishedIndexes = append(finishedIndexes, idx)
+	}
+
+	team, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, 0, 0, levels, fmt.Errorf("invalid team: %v", err)
+	}
+	levelsUp, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return nil, 0, 0, levels, fmt.Errorf("invalid number of levels: %v", err)
+	}
+
+	levelStrs := strings.Split(parts[3], ",")
+	if len(levelStrs) < 2 {
+		return nil, 0, 0, levels, fmt.Errorf("invalid levels: %s", parts[3])
+	}
+	for _, levelStr := range levelStrs {
+		level, err := ParseRank(levelStr)
+		if err != nil {
+			return nil, 0, 0, nil, fmt.Errorf("failed to parse level: %v", err)
+		}
+		levels = append(levels, level)
+	}
+
+	return finishedIndexes, team, levelsUp, levels, nil
+}
+
+// ConstructTributeServerMessage constructs a tribute message string from the index of the player giving a card,
+// the index of the player receiving it, the card, which is left out for tributeDue messages,
+// and the teams of both players
+func ConstructTributeServerMessage(from int, to int, card *Card, fromTeam int, toTeam int) string {
+	cardStr := ""
+	if card != nil {
+		cardStr = card.CardString()
+	}
+	return fmt.Sprintf("%d;%d;%s;%d;%d", from, to, cardStr, fromTeam, toTeam)
+}
+
+// ParseTributeServerMessage parses a tribute message string into its components,
+// the card is nil if the message does not carry one
+func ParseTributeServerMessage(msg string) (int, int, *Card, [2]int, error) {
+	var teams [2]int
+	parts := strings.SplitN(msg, ";", 5)
+	if len(parts) != 5 {
+		return 0, 0, nil, teams, fmt.Errorf("invalid message format: expected 5 parts separated by ';'")
+	}
+
+	from, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, nil, teams, fmt.Errorf("invalid player index: %v", err)
+	}
+	to, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, nil, teams, fmt.Errorf("invalid player index: %v", err)
+	}
+	for t, teamStr := range parts[3:] {
+		if teams[t], err = strconv.Atoi(teamStr); err != nil {
+			return 0, 0, nil, teams, fmt.Errorf("invalid team: %v", err)
+		}
+	}
+	if parts[2] == "" {
+		return from, to, nil, teams, nil
+	}
+	card, err := ParseCard(parts[2])
+	if err != nil {
+		return 0, 0, nil, teams, fmt.Errorf("failed to parse card: %v", err)
+	}
+	return from, to, &card, teams, nil
+}
+
 // ConstructClientPlayMessage constructs a play message string from the given attempt and equivalent cards
-// equivalent can be nil
-func ConstructClientPlayMessage(attempt []Card, numCardsLeft int, equivalent []Card) string {
+// and the combination the player declares the play to be
+// equivalent and declared can be nil
+func ConstructClientPlayMessage(attempt []Card, numCardsLeft int, equivalent []Card, declared *Combination) string {
 	if equivalent == nil {
 		equivalent = []Card{}
 	}
-	return fmt.Sprintf("%s;%d;%s", CardsString(attempt), numCardsLeft, CardsString(equivalent))
+	msg := fmt.Sprintf("%s;%d;%s", CardsString(attempt), numCardsLeft, CardsString(equivalent))
+	if declared != nil {
+		msg += ";" + declared.String()
+	}
+	return msg
 }
 
-// ParseClientPlayMessage parses a play message string into its components
-func ParseClientPlayMessage(msg string) (DeckAPI, int, DeckAPI, error) {
+// ParseClientPlayMessage parses a play message string into its components,
+// the declared combination is nil if the player did not declare one
+func ParseClientPlayMessage(msg string) (DeckAPI, int, DeckAPI, *Combination, error) {
 	// Split the message by semicolon
-	parts := strings.SplitN(msg, ";", 3)
-	if len(parts) != 3 {
-		return nil, 0, nil, fmt.Errorf("invalid message format: expected 3 parts separated by ';'")
+	parts := strings.SplitN(msg, ";", 4)
+	if len(parts) < 3 {
+		return nil, 0, nil, nil, fmt.Errorf("invalid message format: expected 3 or 4 parts separated by ';'")
 	}
 
 	attemptStr := parts[0]
 	numCardsLeft, err := strconv.Atoi(parts[1])
 	if err != nil {
-		return nil, 0, nil, fmt.Errorf("invalid number of cards left: %v", err)
+		return nil, 0, nil, nil, fmt.Errorf("invalid number of cards left: %v", err)
 	}
 	equivalentStr := parts[2]
 
 	// Parse attempt cards
 	attemptDeck, err := NewDeckFromString(attemptStr)
 	if err != nil {
-		return nil, 0, nil, fmt.Errorf("failed to parse attempt cards: %v", err)
+		return nil, 0, nil, nil, fmt.Errorf("failed to parse attempt cards: %v", err)
 	}
 
 	// Parse equivalent cards
 	equivalentDeck, err := NewDeckFromString(equivalentStr)
 	if err != nil {
-		return nil, 0, nil, fmt.Errorf("failed to parse equivalent cards: %v", err)
+		return nil, 0, nil, nil, fmt.Errorf("failed to parse equivalent cards: %v", err)
+	}
+
+	// Parse the declared combination
+	if len(parts) < 4 || parts[3] == "" {
+		return attemptDeck, numCardsLeft, equivalentDeck, nil, nil
+	}
+	declared, err := ParseCombination(parts[3], attemptDeck.Count())
+	if err != nil {
+		return nil, 0, nil, nil, fmt.Errorf("failed to parse declared combination: %v", err)
 	}
 
-	return attemptDeck, numCardsLeft, equivalentDeck, nil
+	return attemptDeck, numCardsLeft, equivalentDeck, &declared, nil
 }
 
 // BuildClientMessage is a helper function to build a structured client message
@@ -163,6 +354,42 @@ func BuildClientMessage(index int, action string, data interface{}) []byte {
 	return message
 }
 
+// BuildClientPlayMessage builds a "play" client message from the play data,
+// if dryRun is true the server only validates the play and answers with validPlay or invalidPlay
+func BuildClientPlayMessage(index int, data string, dryRun bool) []byte {
+	msg := ClientMessage{
+		Index:  index,
+		Action: "play",
+		Data:   data,
+		DryRun: dryRun,
+	}
+
+	message, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("Error marshaling client message: %v", err)
+		return nil
+	}
+	return message
+}
+
+// BuildClientJoinMessage builds a "join" client message for the seat at index,
+// token is the seat token to take the seat back with and can be empty
+func BuildClientJoinMessage(index int, name string, token string) []byte {
+	msg := ClientMessage{
+		Index:  index,
+		Action: "join",
+		Data:   name,
+		Token:  token,
+	}
+
+	message, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("Error marshaling client message: %v", err)
+		return nil
+	}
+	return message
+}
+
 // ParseClientMessage parses a JSON-encoded client message into a ClientMessage struct.
 // It returns the parsed message and any error encountered.
 func ParseClientMessage(data []byte) (*ClientMessage, error) {
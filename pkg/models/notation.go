@@ -0,0 +1,305 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Notation is a style of writing cards
+type Notation string
+
+// Constants for notations
+const (
+	NotationProtocol Notation = "protocol" // "10-H", "A-S#2", "Jr": the notation of the protocol, with deck numbers
+	NotationCompact  Notation = "compact"  // "TH", "AS", "SJ": rank then suit letter
+	NotationSymbol   Notation = "symbol"   // "10♥", "A♠", "Jr": rank then suit symbol, as cards are shown
+	NotationChinese  Notation = "chinese"  // "红桃10", "黑桃A", "小王": Chinese suit name then rank
+)
+
+// suitNames lists the names a suit can be written with, the longer names first
+var suitNames = []struct {
+	name string
+	suit Suit
+}{
+	{"黑桃", Spade}, {"红桃", Heart}, {"红心", Heart}, {"方块", Diamond}, {"方片", Diamond}, {"梅花", Club}, {"草花", Club},
+	{string(Spade), Spade}, {string(Heart), Heart}, {string(Diamond), Diamond}, {string(Club), Club},
+	{"S", Spade}, {"H", Heart}, {"D", Diamond}, {"C", Club},
+}
+
+// chineseSuits holds the Chinese name of each suit
+var chineseSuits = map[Suit]string{Spade: "黑桃", Heart: "红桃", Diamond: "方块", Club: "梅花"}
+
+// ParseNotation parses the name of a notation
+func ParseNotation(s string) (Notation, error) {
+	switch n := Notation(strings.ToLower(s)); n {
+	case NotationProtocol, NotationCompact, NotationSymbol, NotationChinese:
+		return n, nil
+	default:
+		return "", fmt.Errorf("invalid notation: %s, use protocol, compact, symbol or chinese", s)
+	}
+}
+
+// SuitToInitial returns the single-letter string representation of a suit
+// S for Spade, H for Heart, D for Diamond, and C for Club
+func SuitToInitial(s Suit) string {
+	switch s {
+	case Spade:
+		return "S"
+	case Heart:
+		return "H"
+	case Diamond:
+		return "D"
+	case Club:
+		return "C"
+	default:
+		return "?"
+	}
+}
+
+// RankToString returns the protocol notation of a rank: 2 to 10, J, Q, K, A, Jr and BJr
+func RankToString(r Rank) string {
+	switch r {
+	case Jack:
+		return "J"
+	case Queen:
+		return "Q"
+	case King:
+		return "K"
+	case Ace:
+		return "A"
+	case Joker:
+		return "Jr"
+	case BigJoker:
+		return "BJr"
+	default:
+		return fmt.Sprintf("%d", r)
+	}
+}
+
+// ParseRank parses a rank written in any notation, in upper or lower case:
+// 2 to 10 or T, J, Q, K, A, and Jr, SJ or 小王 for the small joker and BJr, BJ or 大王 for the big joker
+func ParseRank(s string) (Rank, error) {
+	text := strings.ToUpper(strings.TrimSpace(s))
+	switch text {
+	case "T", "10":
+		return Ten, nil
+	case "J":
+		return Jack, nil
+	case "Q":
+		return Queen, nil
+	case "K":
+		return King, nil
+	case "A":
+		return Ace, nil
+	case "JR", "SJ", "小王":
+		return Joker, nil
+	case "BJR", "BJ", "大王":
+		return BigJoker, nil
+	}
+	if n, err := strconv.Atoi(text); err == nil && n >= int(Two) && n <= int(Nine) {
+		return Rank(n), nil
+	}
+	return 0, fmt.Errorf("invalid rank: %s", s)
+}
+
+// ParseSuit parses a suit written as a letter in upper or lower case, a symbol or a Chinese name
+func ParseSuit(s string) (Suit, error) {
+	text := strings.ToUpper(strings.TrimSpace(s))
+	for _, n := range suitNames {
+		if text == n.name {
+			return n.suit, nil
+		}
+	}
+	return "", fmt.Errorf("invalid suit: %s", s)
+}
+
+// ParseCard parses a card written in any notation, in upper or lower case, into a Card struct.
+// Supported formats are the protocol notation "10-H", "A-S", "Jr" and "BJr", the rank before or after
+// the suit as in "10H", "TH", "h10", "♥10" or "红桃10", and "SJ", "BJ", "小王" and "大王" for the jokers.
+// The card may be followed by the deck number of a dealt card, as in "A-S#2" or "Jr#1".
+// SJ is the small joker, the jack of spades is written S-J or JS.
+func ParseCard(cardStr string) (Card, error) {
+	valueStr, deckStr, hasDeck := strings.Cut(strings.TrimSpace(cardStr), "#")
+	card, err := parseCardValue(valueStr)
+	if err != nil || !hasDeck {
+		return card, err
+	}
+	card.Deck, err = strconv.Atoi(deckStr)
+	if err != nil || card.Deck < 1 {
+		return Card{}, fmt.Errorf("invalid deck number: %s", cardStr)
+	}
+	return card, nil
+}
+
+// parseCardValue parses a card string without deck number
+func parseCardValue(cardStr string) (Card, error) {
+	// Handle jokers
+	if rank, err := ParseRank(cardStr); err == nil && rank >= Joker {
+		return Card{Rank: rank}, nil
+	}
+
+	// Find the suit before or after the rank
+	text := strings.ToUpper(strings.ReplaceAll(cardStr, "-", ""))
+	for _, n := range suitNames {
+		var rankStr string
+		switch {
+		case strings.HasPrefix(text, n.name):
+			rankStr = text[len(n.name):]
+		case strings.HasSuffix(text, n.name):
+			rankStr = text[:len(text)-len(n.name)]
+		default:
+			continue
+		}
+		if rank, err := ParseRank(rankStr); err == nil && rank <= Ace {
+			return NewCard(n.suit, rank), nil
+		}
+	}
+	return Card{}, fmt.Errorf("invalid card format: %s", cardStr)
+}
+
+// FormatCard writes a card in the given notation
+func FormatCard(c Card, notation Notation) string {
+	switch notation {
+	case NotationCompact:
+		if c.Rank >= Joker {
+			return compactJoker(c.Rank)
+		}
+		return compactRank(c.Rank) + SuitToInitial(c.Suit)
+	case NotationSymbol:
+		return c.String()
+	case NotationChinese:
+		switch c.Rank {
+		case Joker:
+			return "小王"
+		case BigJoker:
+			return "大王"
+		}
+		return chineseSuits[c.Suit] + RankToString(c.Rank)
+	default:
+		if c.Deck > 0 {
+			return fmt.Sprintf("%s#%d", formatProtocolValue(c), c.Deck)
+		}
+		return formatProtocolValue(c)
+	}
+}
+
+// FormatCards writes cards in the given notation separated by spaces
+func FormatCards(cards []Card, notation Notation) string {
+	strs := make([]string, len(cards))
+	for i, card := range cards {
+		strs[i] = FormatCard(card, notation)
+	}
+	return strings.Join(strs, " ")
+}
+
+// formatProtocolValue writes a card in the protocol notation, without its deck number
+func formatProtocolValue(c Card) string {
+	if c.Rank >= Joker {
+		return RankToString(c.Rank)
+	}
+	return RankToString(c.Rank) + "-" + SuitToInitial(c.Suit)
+}
+
+// compactRank writes a rank as a single character, T for ten
+func compactRank(r Rank) string {
+	if r == Ten {
+		return "T"
+	}
+	return RankToString(r)
+}
+
+// compactJoker writes a joker as SJ or BJ
+func compactJoker(r Rank) string {
+	if r == BigJoker {
+		return "BJ"
+	}
+	return "SJ"
+}
+
+// ParseHand parses cards written as a compact string such as "KKK22", "T J Q K A" or "KsKhKd2♠2♣".
+// Each rank, written as in ParseRank, may be followed by a suit hint, a lower case letter or a suit symbol.
+// Jokers are written SJ, BJ, Jr, BJr, 小王 or 大王, an upper case S never being a suit hint.
+// Cards without a suit hint have no suit and stand for a card of that rank of any suit.
+func ParseHand(s string) ([]Card, error) {
+	var cards []Card
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		if unicode.IsSpace(r) || r == ',' {
+			i++
+			continue
+		}
+		rest := string(runes[i:])
+		if joker, n := parseCompactJoker(rest); n > 0 {
+			cards = append(cards, Card{Rank: joker})
+			i += n
+			continue
+		}
+
+		n := 1
+		if strings.HasPrefix(rest, "10") {
+			n = 2
+		}
+		rank, err := ParseRank(string(runes[i : i+n]))
+		if err != nil || rank > Ace {
+			return nil, fmt.Errorf("invalid rank at %q", rest)
+		}
+		i += n
+		card := Card{Rank: rank}
+		if i < len(runes) {
+			if suit, ok := suitHint(runes[i]); ok {
+				card.Suit = suit
+				i++
+			}
+		}
+		cards = append(cards, card)
+	}
+	return cards, nil
+}
+
+// parseCompactJoker returns the joker s starts with and the number of runes of its name, 0 if s starts with no joker
+func parseCompactJoker(s string) (Rank, int) {
+	for _, j := range []struct {
+		name string
+		rank Rank
+	}{{"BJr", BigJoker}, {"BJ", BigJoker}, {"大王", BigJoker}, {"SJ", Joker}, {"Jr", Joker}, {"小王", Joker}} {
+		if strings.HasPrefix(s, j.name) {
+			return j.rank, len([]rune(j.name))
+		}
+	}
+	return 0, 0
+}
+
+// suitHint returns the suit a suit hint of a compact string stands for
+func suitHint(r rune) (Suit, bool) {
+	switch r {
+	case 's', '♠':
+		return Spade, true
+	case 'h', '♥':
+		return Heart, true
+	case 'd', '♦':
+		return Diamond, true
+	case 'c', '♣':
+		return Club, true
+	default:
+		return "", false
+	}
+}
+
+// FormatHand writes cards as a compact string such as "KKK22", with suit hints as in "KsKhKd2s2c" if hints is true
+func FormatHand(cards []Card, hints bool) string {
+	var result strings.Builder
+	for _, card := range cards {
+		if card.Rank >= Joker {
+			result.WriteString(compactJoker(card.Rank))
+			continue
+		}
+		result.WriteString(compactRank(card.Rank))
+		if hints && card.Suit != "" {
+			result.WriteString(strings.ToLower(SuitToInitial(card.Suit)))
+		}
+	}
+	return result.String()
+}
@@ -1,6 +1,6 @@
 package models
 
-// Player represents a player in the card game
+// Player represents a player in the card game, the state of one seat at the table
 type Player struct {
 	// index is the index of the player
 	index int
@@ -9,13 +9,43 @@ type Player struct {
 	name string
 
 	// hand contains the player's current cards as a Deck
-	hand Deck
-	// finishedRank is the rank of the player when the round ends
+	hand *Deck
+	// finishedRank is the rank of the player when the round ends, 0 while the player is still playing
 	finishedRank int
+	// token is the secret the player takes the seat back with
+	token string
+	// away is true if the player lost the connection mid-round and the seat is kept for them
+	away bool
+	// stats counts what the player did at the table
+	stats PlayerStats
 	// infoAPI is the interface for game information
 	info InfoAPI
 }
 
+// PlayerStats counts what a player did at the table since taking the seat
+type PlayerStats struct {
+	// Rounds is the number of rounds the player finished
+	Rounds int `json:"rounds"`
+	// FirstPlaces is the number of rounds the player went out first
+	FirstPlaces int `json:"firstPlaces"`
+	// Plays is the number of plays the player made
+	Plays int `json:"plays"`
+	// Passes is the number of times the player passed
+	Passes int `json:"passes"`
+	// CardsPlayed is the number of cards the player played
+	CardsPlayed int `json:"cardsPlayed"`
+}
+
+// NewPlayer creates the player seated at index under name, keeping the game information up to date through info
+func NewPlayer(index int, name string, info InfoAPI) *Player {
+	return &Player{
+		index: index,
+		name:  name,
+		hand:  &Deck{},
+		info:  info,
+	}
+}
+
 // GetIndex returns the player's index
 func (p *Player) GetIndex() int {
 	return p.index
@@ -26,38 +56,85 @@ func (p *Player) Sit(index int) {
 	p.index = index
 }
 
-// ReadyToStart marks the player as ready to start by updating the ready status in info
-func (p *Player) ReadyToStart() {
+// GetTeam returns the team of the player
+func (p *Player) GetTeam() int {
 	if p.info == nil {
-		return
+		return TeamOf(p.index)
 	}
-	p.info.GetReadyToStartMap()[p.index] = true
+	return p.info.GetTeamOf(p.index)
 }
 
-// ReadyToPlay marks the player as ready to play by updating the readyToPlay map in info
-func (p *Player) ReadyToPlay() {
+// ReadyToStart marks the player as ready to start by updating the ready status in info,
+// it returns true if every player is now ready
+func (p *Player) ReadyToStart() bool {
 	if p.info == nil {
-		return
+		return false
 	}
-	p.info.AddReadyToPlay(p.index)
+	return p.info.MarkReady(p.index)
 }
 
-// Pass advances the current player index to the next player, wrapping around to 0 if needed
+// ReadyToPlay marks the player as ready to play by updating the readyToPlay map in info,
+// it returns true if every player is now ready
+func (p *Player) ReadyToPlay() bool {
+	if p.info == nil {
+		return false
+	}
+	return p.info.MarkReadyToPlay(p.index)
+}
+
+// Play removes attempt from the player's hand and puts its equivalent on top of the table in info,
+// it returns false and changes nothing if the hand does not hold attempt
+func (p *Player) Play(attempt []Card, equivalent []Card) bool {
+	if !p.hand.PlayN(attempt) {
+		return false
+	}
+	p.stats.Plays++
+	p.stats.CardsPlayed += len(attempt)
+	if p.info != nil {
+		p.info.RecordPlay(p.index, equivalent)
+	}
+	return true
+}
+
+// Pass records in info that the player passed on the play on top of the table
 func (p *Player) Pass() {
+	p.stats.Passes++
 	if p.info == nil {
 		return
 	}
-	current := p.info.GetCurrentPlayerIndex()
-	next := (current + 1) % p.info.GetNumPlayers()
-	p.info.SetCurrentPlayerIndex(next)
+	p.info.AddPassedIndex(p.index)
 }
 
-// LeaveGame resets the ready status for the player in info
+// Finish records that the player went out in the given place of the round, starting at 1
+func (p *Player) Finish(place int) {
+	p.finishedRank = place
+	p.stats.Rounds++
+	if place == 1 {
+		p.stats.FirstPlaces++
+	}
+}
+
+// LeaveGame marks the player as away, the seat is kept for them until they come back
 func (p *Player) LeaveGame() {
+	p.away = true
+	if p.info == nil {
+		return
+	}
+	p.info.SetAway(p.index, true)
+}
+
+// ComeBack marks an away player as back at the table
+func (p *Player) ComeBack() {
+	p.away = false
 	if p.info == nil {
 		return
 	}
-	p.info.RemoveReadyToPlay(p.index)
+	p.info.SetAway(p.index, false)
+}
+
+// IsAway returns true if the player lost the connection mid-round and the seat is kept for them
+func (p *Player) IsAway() bool {
+	return p.away
 }
 
 // GetName returns the player's name
@@ -71,13 +148,14 @@ func (p *Player) SetName(name string) {
 }
 
 // GetHand returns the player's hand
-func (p *Player) GetHand() Deck {
+func (p *Player) GetHand() *Deck {
 	return p.hand
 }
 
-// SetHand sets the player's hand
-func (p *Player) SetHand(hand Deck) {
+// SetHand sets the player's hand and clears the finished rank of the last round
+func (p *Player) SetHand(hand *Deck) {
 	p.hand = hand
+	p.finishedRank = 0
 }
 
 // GetFinishedRank returns the player's finished rank
@@ -89,3 +167,23 @@ func (p *Player) GetFinishedRank() int {
 func (p *Player) SetFinishedRank(rank int) {
 	p.finishedRank = rank
 }
+
+// GetToken returns the secret the player takes the seat back with
+func (p *Player) GetToken() string {
+	return p.token
+}
+
+// SetToken sets the secret the player takes the seat back with
+func (p *Player) SetToken(token string) {
+	p.token = token
+}
+
+// GetStats returns what the player did at the table
+func (p *Player) GetStats() PlayerStats {
+	return p.stats
+}
+
+// SetStats sets what the player did at the table
+func (p *Player) SetStats(stats PlayerStats) {
+	p.stats = stats
+}
@@ -0,0 +1,38 @@
+package models
+
+// PlayerAPI defines the interface for the state of one seat at the table
+type PlayerAPI interface {
+	// Seat
+	GetIndex() int
+	Sit(index int)
+	GetTeam() int
+	GetName() string
+	SetName(name string)
+	GetToken() string
+	SetToken(token string)
+
+	// Ready states
+	ReadyToStart() bool
+	ReadyToPlay() bool
+
+	// Hand and plays
+	GetHand() *Deck
+	SetHand(hand *Deck)
+	Play(attempt []Card, equivalent []Card) bool
+	Pass()
+	Finish(place int)
+	GetFinishedRank() int
+	SetFinishedRank(rank int)
+
+	// Connection
+	LeaveGame()
+	ComeBack()
+	IsAway() bool
+
+	// Statistics
+	GetStats() PlayerStats
+	SetStats(stats PlayerStats)
+}
+
+// Verify at compile time that *Player implements PlayerAPI
+var _ PlayerAPI = (*Player)(nil)
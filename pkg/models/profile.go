@@ -0,0 +1,223 @@
+package models
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// BombOrder decides where straight flushes rank among the bombs
+type BombOrder string
+
+// Constants for bomb orders
+const (
+	BombOrderStandard  BombOrder = "standard"  // a straight flush beats bombs of up to five cards and loses to bigger ones
+	BombOrderFlushHigh BombOrder = "flushHigh" // a straight flush beats every bomb of cards of the same rank
+)
+
+// FirstLead decides who leads the first round of a match, later rounds are led as the tribute decides
+type FirstLead string
+
+// Constants for first leads
+const (
+	FirstLeadRandom FirstLead = "random" // a player drawn at random leads
+	FirstLeadReveal FirstLead = "reveal" // a card is turned face up during the deal and whoever receives its twin leads
+)
+
+// RuleProfile holds the house rules a table is played with
+type RuleProfile struct {
+	// Name identifies the profile
+	Name string `json:"name"`
+	// JieFeng gives the lead to the partner of a player who went out with a play nobody beat
+	JieFeng bool `json:"jieFeng"`
+	// Tribute makes the losers of a round pay tribute to the winners before the next round
+	Tribute bool `json:"tribute"`
+	// AceLow lets an ace count as one in straights, as in A-2-3-4-5
+	AceLow bool `json:"aceLow"`
+	// MaxAAttempts is the number of rounds a team can play at level A before it falls back to level Two
+	MaxAAttempts int `json:"maxAAttempts"`
+	// BombOrder decides where straight flushes rank among the bombs
+	BombOrder BombOrder `json:"bombOrder"`
+	// MaxCardsPerPlay is the most cards a single play can hold, 0 for no limit
+	MaxCardsPerPlay int `json:"maxCardsPerPlay"`
+	// FirstLead decides who leads the first round of a match
+	FirstLead FirstLead `json:"firstLead"`
+}
+
+// DefaultRuleProfile returns the rules played when no profile is given
+func DefaultRuleProfile() RuleProfile {
+	return RuleProfile{
+		Name:         "standard",
+		JieFeng:      true,
+		Tribute:      true,
+		AceLow:       true,
+		MaxAAttempts: MaxAAttempts,
+		BombOrder:    BombOrderStandard,
+		FirstLead:    FirstLeadReveal,
+	}
+}
+
+// LoadRuleProfile reads a rule profile from a JSON file, or a YAML file if the name ends in .yaml or .yml.
+// Settings missing from the file keep their default value.
+func LoadRuleProfile(path string) (RuleProfile, error) {
+	profile := DefaultRuleProfile()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return profile, fmt.Errorf("failed to read rule profile: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if data, err = yamlToJSON(data, reflect.TypeOf(profile)); err != nil {
+			return profile, fmt.Errorf("failed to parse rule profile %s: %w", path, err)
+		}
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&profile); err != nil {
+		return profile, fmt.Errorf("failed to parse rule profile %s: %w", path, err)
+	}
+	return profile, profile.Validate()
+}
+
+// Validate returns an error if the profile holds a setting that cannot be played
+func (p RuleProfile) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("rule profile has no name")
+	}
+	if p.MaxAAttempts < 1 {
+		return fmt.Errorf("rule profile %s: at least 1 attempt at level A is needed, got %d", p.Name, p.MaxAAttempts)
+	}
+	switch p.BombOrder {
+	case BombOrderStandard, BombOrderFlushHigh:
+	default:
+		return fmt.Errorf("rule profile %s: invalid bomb order: %s", p.Name, p.BombOrder)
+	}
+	switch p.FirstLead {
+	case FirstLeadRandom, FirstLeadReveal:
+	default:
+		return fmt.Errorf("rule profile %s: invalid first lead: %s", p.Name, p.FirstLead)
+	}
+	if p.MaxCardsPerPlay != 0 && p.MaxCardsPerPlay < 6 {
+		return fmt.Errorf("rule profile %s: plays of up to 6 cards must be allowed, got %d", p.Name, p.MaxCardsPerPlay)
+	}
+	return nil
+}
+
+// String returns a one line summary of the profile for players
+func (p RuleProfile) String() string {
+	onOff := map[bool]string{true: "on", false: "off"}
+	maxCards := "no limit"
+	if p.MaxCardsPerPlay > 0 {
+		maxCards = strconv.Itoa(p.MaxCardsPerPlay)
+	}
+	return fmt.Sprintf("%s: jie feng %s, tribute %s, ace low %s, %d attempts at A, bomb order %s, max cards per play %s, first lead %s",
+		p.Name, onOff[p.JieFeng], onOff[p.Tribute], onOff[p.AceLow], p.MaxAAttempts, p.BombOrder, maxCards, p.FirstLead)
+}
+
+// yamlToJSON converts a flat YAML document of "key: value" lines to a JSON object decoding into a struct of type target.
+// Comments and blank lines are skipped, and each value is read as the type of the field of target its key names:
+// a boolean, a number or a string. Keys naming no field are kept as strings for the decoder to report.
+func yamlToJSON(data []byte, target reflect.Type) ([]byte, error) {
+	kinds := make(map[string]reflect.Kind)
+	for i := 0; i < target.NumField(); i++ {
+		field := target.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		kinds[name] = field.Type.Kind()
+	}
+
+	fields := make(map[string]interface{})
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(stripComment(scanner.Text()))
+		if text == "" || text == "---" {
+			continue
+		}
+		key, value, ok := strings.Cut(text, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key: value", line)
+		}
+		key, value = strings.TrimSpace(key), unquote(strings.TrimSpace(value))
+		switch kinds[key] {
+		case reflect.Bool:
+			b, err := parseYAMLBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s must be true or false, got %q", line, key, value)
+			}
+			fields[key] = b
+		case reflect.Int:
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s must be a number, got %q", line, key, value)
+			}
+			fields[key] = n
+		default:
+			fields[key] = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+// stripComment returns the line without its comment, which starts at a # outside quotes
+// at the start of the line or after a space
+func stripComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// unquote returns value without the quotes around it, if it is quoted
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// parseYAMLBool reads a YAML boolean: true or false, also written yes or no and on or off
+func parseYAMLBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "yes", "on":
+		return true, nil
+	case "no", "off":
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+// ConstructRulesServerMessage constructs the data of a rules server message from the given profile
+func ConstructRulesServerMessage(profile RuleProfile) string {
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// ParseRulesServerMessage parses the data of a rules server message
+func ParseRulesServerMessage(msg string) (*RuleProfile, error) {
+	var profile RuleProfile
+	if err := json.Unmarshal([]byte(msg), &profile); err != nil {
+		return nil, fmt.Errorf("failed to parse rules message: %w", err)
+	}
+	return &profile, nil
+}
@@ -2,12 +2,16 @@ package models
 
 import "sort"
 
-// NumOfDecks calculates the number of decks needed based on the number of players.
+// NumOfDecks calculates the number of decks needed based on the number of players,
+// one deck for every two players and at least one deck
 func NumOfDecks(numOfPlayers int) int {
 	if numOfPlayers <= 0 {
 		return 0
 	}
-	return int(float64(numOfPlayers) * 0.5)
+	if numOfPlayers < 4 {
+		return 1
+	}
+	return numOfPlayers / 2
 }
 
 // Rule represents the game rules and logic
@@ -15,12 +19,27 @@ func NumOfDecks(numOfPlayers int) int {
 // and how game state transitions occur
 type Rule struct {
 	info InfoAPI
+	// profile holds the house rules, the zero profile plays the default rules
+	profile RuleProfile
 }
 
 func (r *Rule) SetInfo(info *Info) {
 	r.info = info
 }
 
+// Profile returns the house rules played, DefaultRuleProfile if none were set
+func (r *Rule) Profile() RuleProfile {
+	if r.profile.Name == "" {
+		return DefaultRuleProfile()
+	}
+	return r.profile
+}
+
+// SetProfile sets the house rules played
+func (r *Rule) SetProfile(profile RuleProfile) {
+	r.profile = profile
+}
+
 // isPlayValid validates a card play according to the game rules
 // It returns true for the following cases:
 // 1. Single card
@@ -30,244 +49,157 @@ func (r *Rule) SetInfo(info *Info) {
 // 5. Five cards: all same rank, or full house (3+2), or straight (5 consecutive ranks)
 // 6. Six cards: all same rank, or three pairs with consecutive ranks, or two triplets with consecutive ranks
 // 7. Seven or more cards of the same rank
+// In straights and consecutive pairs or triples an ace is high or, when aces are low, low,
+// but a run never wraps around from an ace to a two and never holds a joker
 func (r *Rule) IsPlayValid(play []Card) bool {
+	return r.CheckPlay(play) == nil
+}
+
+// CheckPlay validates a card play like IsPlayValid.
+// It returns nil for a valid play, otherwise a *ProtocolError explaining why the combination is illegal
+func (r *Rule) CheckPlay(play []Card) error {
+	if max := r.Profile().MaxCardsPerPlay; max > 0 && len(play) > max {
+		return illegalCombination("a play can hold at most %d cards", max)
+	}
 	switch len(play) {
 	case 0:
-		return false
+		return illegalCombination("no cards played")
 	case 1:
-		return true // Single card is always valid
+		return nil // Single card is always valid
 	case 2:
 		// Must be a pair (same rank)
-		return play[0].Rank == play[1].Rank
-	case 3:
-		// Must be three of a kind
-		return play[0].Rank == play[1].Rank && play[1].Rank == play[2].Rank
-	case 4:
-		// Must be four of a kind
-		return play[0].Rank == play[1].Rank &&
-			play[1].Rank == play[2].Rank &&
-			play[2].Rank == play[3].Rank
+		if play[0].Rank != play[1].Rank {
+			if err := r.checkRunLength(play); err != nil {
+				return err
+			}
+			return illegalCombination("pair ranks differ")
+		}
+	case 3, 4:
+		// Must be three or four of a kind
+		if !r.allSameRank(play) {
+			if err := r.checkRunLength(play); err != nil {
+				return err
+			}
+			return illegalCombination("%d cards must all have the same rank", len(play))
+		}
 	case 5:
-		return r.isValidFiveCardPlay(play)
+		return r.checkFiveCardPlay(play)
 	case 6:
-		return r.isValidSixCardPlay(play)
+		return r.checkSixCardPlay(play)
 	default: // 7 or more cards
 		// Must all be the same rank
-		return r.allSameRank(play)
+		if !r.allSameRank(play) {
+			if err := r.checkRunLength(play); err != nil {
+				return err
+			}
+			return illegalCombination("%d cards must all have the same rank", len(play))
+		}
 	}
+	return nil
 }
 
-func (r *Rule) IsCounterPlayValid(play []Card, counterPlay []Card) bool {
-	if len(play) == 0 {
-		return false // No play to counter
-	} else if len(play) == 1 {
-		// 1. counterPlay is a single card, and its rank is greater than play
-		if len(counterPlay) == 1 && r.IsRankGreater(counterPlay[0].Rank, play[0].Rank) {
-			return true
-		}
-
-		// 2. counterPlay has 4 or more cards and all the cards are of the same rank
-		if len(counterPlay) >= 4 && r.allSameRank(counterPlay) {
-			return true
-		}
-
-		// 3. counterPlay is a straight flush
-		if r.isStraightFlush(counterPlay) {
-			return true
-		}
-
-		// Otherwise, not a valid counter play
-		return false
-	} else if len(play) == 2 {
-		// 1. counterPlay is a pair with a higher rank
-		if len(counterPlay) == 2 && counterPlay[0].Rank == counterPlay[1].Rank && r.IsRankGreater(counterPlay[0].Rank, play[0].Rank) {
-			return true
-		}
-
-		// 2. counterPlay has 4 or more cards and all the cards are of the same rank
-		if len(counterPlay) >= 4 && r.allSameRank(counterPlay) {
-			return true
-		}
-
-		// 3. counterPlay is a straight flush
-		if r.isStraightFlush(counterPlay) {
-			return true
-		}
-
-		// Otherwise, not a valid counter play
-		return false
-	} else if len(play) == 3 {
-		// 1. counterPlay is a three of a kind with a higher rank
-		if len(counterPlay) == 3 && counterPlay[0].Rank == counterPlay[1].Rank && counterPlay[1].Rank == counterPlay[2].Rank && r.IsRankGreater(counterPlay[0].Rank, play[0].Rank) {
-			return true
-		}
-
-		// 2. counterPlay has 4 or more cards and all the cards are of the same rank
-		if len(counterPlay) >= 4 && r.allSameRank(counterPlay) {
-			return true
-		}
+// CheckCounterPlay validates counterPlay against play like IsCounterPlayValid.
+// It returns nil for a valid counter play, otherwise a *ProtocolError explaining
+// whether counterPlay is an illegal combination or does not beat play
+func (r *Rule) CheckCounterPlay(play []Card, counterPlay []Card) error {
+	if err := r.CheckPlay(counterPlay); err != nil {
+		return err
+	}
+	if !r.IsCounterPlayValid(play, counterPlay) {
+		return NewProtocolError(CodeDoesNotBeatTable, "%s does not beat %s", CardsString(counterPlay), CardsString(play))
+	}
+	return nil
+}
 
-		// 3. counterPlay is a straight flush
-		if r.isStraightFlush(counterPlay) {
-			return true
-		}
+// illegalCombination returns a *ProtocolError for a play that is not a legal combination
+func illegalCombination(format string, args ...interface{}) *ProtocolError {
+	return NewProtocolError(CodeIllegalCombination, format, args...)
+}
 
-		// Otherwise, not a valid counter play
+// IsCounterPlayValid checks if counterPlay beats play, comparing the two plays by their combinations
+func (r *Rule) IsCounterPlayValid(play []Card, counterPlay []Card) bool {
+	playCombination, err := r.Classify(play)
+	if err != nil {
+		return false // No play to counter
+	}
+	counterCombination, err := r.Classify(counterPlay)
+	if err != nil {
 		return false
-	} else if len(play) == 4 {
-		// 1. counterPlay is a four of a kind with a higher rank
-		if len(counterPlay) == 4 && counterPlay[0].Rank == counterPlay[1].Rank &&
-			counterPlay[1].Rank == counterPlay[2].Rank &&
-			counterPlay[2].Rank == counterPlay[3].Rank &&
-			r.IsRankGreater(counterPlay[0].Rank, play[0].Rank) {
-			return true
-		}
+	}
+	return r.Beats(playCombination, counterCombination)
+}
 
-		// 2. counterPlay has 5 or more cards and all the cards are of the same rank
-		if len(counterPlay) >= 5 && r.allSameRank(counterPlay) {
-			return true
+// LevelUp returns the winning team of a round and how many levels it goes up from the finishing order.
+// The team of the first player to finish wins, and goes up by the level ups of the table for the place
+// its last player finished in. At four players it goes up 3 levels if the partner finished second,
+// 2 levels if the partner finished third and 1 level otherwise.
+func (r *Rule) LevelUp(finishedIndexes []int) (team int, levels int) {
+	if len(finishedIndexes) == 0 {
+		return 0, 0
+	}
+	team = r.teamOf(finishedIndexes[0])
+	last := 0
+	for place, index := range finishedIndexes {
+		if r.teamOf(index) == team {
+			last = place + 1
 		}
+	}
+	return team, r.table(len(finishedIndexes)).LevelsUp(last)
+}
 
-		// 3. counterPlay is a straight flush
-		if r.isStraightFlush(counterPlay) {
-			return true
+// table returns the configuration of the table, or the usual configuration for numPlayers if there is no game info
+func (r *Rule) table(numPlayers int) TableConfig {
+	if r.info != nil {
+		if table := r.info.GetTable(); table.NumPlayers > 0 {
+			return table
 		}
+	}
+	table, _ := NewTableConfig(numPlayers, 0)
+	return table
+}
 
-		// Otherwise, not a valid counter play
-		return false
-	} else if len(play) == 5 {
-		// If play is a five of a kind, return true if counterPlay is a straight flush or counter play is also a five of a kind but with a higher rank
-		if r.allSameRank(play) {
-			if r.isStraightFlush(counterPlay) {
-				return true
-			}
-			if r.allSameRank(counterPlay) && r.IsRankGreater(counterPlay[0].Rank, play[0].Rank) {
-				return true
-			}
-		} else if r.isStraightFlush(play) {
-			// If play is a straight flush, return true if counterPlay is also a straight flush but the ending rank is higher
-			if r.isStraightFlush(counterPlay) && r.IsRankGreater(counterPlay[len(counterPlay)-1].Rank, play[len(play)-1].Rank) {
-				return true
-			}
-		} else if r.isStraight(play) {
-			// If play is a straight, return true if counterPlay is also a straight but the ending rank is higher
-			if r.isStraight(counterPlay) && r.IsRankGreater(counterPlay[len(counterPlay)-1].Rank, play[len(play)-1].Rank) {
-				return true
-			}
-
-			if r.allSameRank(counterPlay) && len(counterPlay) >= 4 {
-				return true
-			}
-
-			if r.isStraightFlush(counterPlay) {
-				return true
-			}
-		} else if r.isFullHouse(play) {
-			if r.isFullHouse(counterPlay) && r.isGreaterFullHouse(play, counterPlay) {
-				return true
-			}
+// teamOf returns the team of the seat at index, partners sit across from each other if there is no game info
+func (r *Rule) teamOf(index int) int {
+	if r.info == nil {
+		return TeamOf(index)
+	}
+	return r.info.GetTeamOf(index)
+}
 
-			if r.allSameRank(counterPlay) && len(counterPlay) >= 4 {
-				return true
-			}
+// NextLevel returns the level reached by going up levels from level, A is the highest level
+func NextLevel(level Rank, levels int) Rank {
+	next := level + Rank(levels)
+	if next > Ace {
+		return Ace
+	}
+	return next
+}
 
-			if r.isStraightFlush(counterPlay) {
-				return true
-			}
-		}
+// IsWildCard returns true if the card is a heart of the trump rank,
+// which can stand in for any non-joker card
+func (r *Rule) IsWildCard(card Card) bool {
+	return card.Suit == Heart && card.Rank == r.info.GetTrumpRank()
+}
 
-		// Otherwise, not a valid counter play
+// IsEquivalentValid checks that equivalent is a legal reading of attempt.
+// Both slices must have the same length, wild cards may stand in for any non-joker card,
+// and every other card must stand for itself.
+func (r *Rule) IsEquivalentValid(attempt []Card, equivalent []Card) bool {
+	if len(attempt) != len(equivalent) {
 		return false
-	} else if len(play) == 6 {
-		// If play is six of a kind
-		if r.allSameRank(play) {
-			// 1. counterPlay is also a six of a kind but with a higher rank
-			if r.allSameRank(counterPlay) && len(counterPlay) == 6 && r.IsRankGreater(counterPlay[0].Rank, play[0].Rank) {
-				return true
-			}
-			// 2. counterPlay is a 7 or more of a kind
-			if r.allSameRank(counterPlay) && len(counterPlay) >= 7 {
-				return true
-			}
-		} else if rankCount := r.countRanks(play); len(rankCount) == 3 {
-			// play is three pairs with consecutive ranks
-			isThreePairs := true
-			for _, count := range rankCount {
-				if count != 2 {
-					isThreePairs = false
-					break
-				}
-			}
-			if isThreePairs && r.areRanksConsecutive(getSortedRanks(rankCount)) {
-				// counterPlay must also be three pairs with consecutive ranks and higher ending rank
-				if len(counterPlay) == 6 {
-					counterRankCount := r.countRanks(counterPlay)
-					isCounterThreePairs := true
-					for _, count := range counterRankCount {
-						if count != 2 {
-							isCounterThreePairs = false
-							break
-						}
-					}
-					if isCounterThreePairs && r.areRanksConsecutive(getSortedRanks(counterRankCount)) {
-						// Compare the highest rank in both plays
-						playRanks := getSortedRanks(rankCount)
-						counterRanks := getSortedRanks(counterRankCount)
-						if r.IsRankGreater(counterRanks[2], playRanks[2]) {
-							return true
-						}
-					}
-				}
-				// counterPlay is a bomb
-				if r.allSameRank(counterPlay) && len(counterPlay) >= 4 {
-					return true
-				}
-			}
-		} else if len(rankCount) == 2 {
-			// play is two triplets with consecutive ranks
-			isTwoTriplets := true
-			for _, count := range rankCount {
-				if count != 3 {
-					isTwoTriplets = false
-					break
-				}
-			}
-			if isTwoTriplets && r.areRanksConsecutive(getSortedRanks(rankCount)) {
-				// counterPlay must also be two triplets with consecutive ranks and higher ending rank
-				if len(counterPlay) == 6 {
-					counterRankCount := r.countRanks(counterPlay)
-					isCounterTwoTriplets := true
-					for _, count := range counterRankCount {
-						if count != 3 {
-							isCounterTwoTriplets = false
-							break
-						}
-					}
-					if isCounterTwoTriplets && r.areRanksConsecutive(getSortedRanks(counterRankCount)) {
-						playRanks := getSortedRanks(rankCount)
-						counterRanks := getSortedRanks(counterRankCount)
-						if r.IsRankGreater(counterRanks[1], playRanks[1]) {
-							return true
-						}
-					}
-				}
-				// counterPlay is a bomb
-				if r.allSameRank(counterPlay) && len(counterPlay) >= 4 {
-					return true
-				}
+	}
+	for i, card := range attempt {
+		if r.IsWildCard(card) {
+			if equivalent[i].Rank == Joker || equivalent[i].Rank == BigJoker {
+				return false
 			}
+			continue
 		}
-		return false
-	} else {
-		if r.allSameRank(counterPlay) {
-			if len(counterPlay) == len(play) && r.IsRankGreater(counterPlay[0].Rank, play[0].Rank) {
-				return true
-			} else if len(counterPlay) > len(play) {
-				return true
-			}
+		if !equivalent[i].SameValue(card) {
+			return false
 		}
-		return false
 	}
+	return true
 }
 
 // IsRankGreater checks if rank1 is greater than rank2
@@ -315,56 +247,49 @@ func (r *Rule) isFullHouse(cards []Card) bool {
 	return hasTwo && hasThree
 }
 
-// isGreaterFullHouse compares two full house hands and returns true if the second hand is greater
-// A full house is ranked by the rank of its three-of-a-kind first, then by the rank of its pair
-func (r *Rule) isGreaterFullHouse(hand1, hand2 []Card) bool {
-	if !r.isFullHouse(hand1) || !r.isFullHouse(hand2) {
-		return false
-	}
-
-	// Get the three-of-a-kind and pair ranks for both hands
-	hand1ThreeRank := r.getFullHouseRanks(hand1)
-	hand2ThreeRank := r.getFullHouseRanks(hand2)
-
-	// First compare the three-of-a-kind rank
-	return r.IsRankGreater(hand2ThreeRank, hand1ThreeRank)
-}
-
-// getFullHouseRanks returns the three-of-a-kind rank of a full house
-func (r *Rule) getFullHouseRanks(cards []Card) Rank {
-	rankCount := r.countRanks(cards)
+// fullHouseTriple returns the rank of the three-of-a-kind of a full house
+func (r *Rule) fullHouseTriple(cards []Card) Rank {
 	var threeRank Rank
-
-	for rank, count := range rankCount {
-		switch count {
-		case 3:
+	for rank, count := range r.countRanks(cards) {
+		if count == 3 {
 			threeRank = rank
 		}
 	}
 	return threeRank
 }
 
-// isValidFiveCardPlay checks if a 5-card play is valid
-func (r *Rule) isValidFiveCardPlay(cards []Card) bool {
+// checkFiveCardPlay checks if a 5-card play is valid
+func (r *Rule) checkFiveCardPlay(cards []Card) error {
 	// Check for five of a kind
 	if r.allSameRank(cards) {
-		return true
+		return nil
 	}
 
 	// Check for full house (3+2)
 	if r.isFullHouse(cards) {
-		return true
+		return nil
 	}
 
 	// Check for straight (5 consecutive ranks)
-	return r.isStraight(cards)
+	if r.isStraight(cards) {
+		return nil
+	}
+
+	switch len(r.countRanks(cards)) {
+	case 2:
+		return illegalCombination("full house needs three cards of one rank and two of another")
+	case 5:
+		return illegalCombination("straight ranks must be consecutive")
+	default:
+		return illegalCombination("5 cards must be a straight, a full house or all the same rank")
+	}
 }
 
-// isValidSixCardPlay checks if a 6-card play is valid
-func (r *Rule) isValidSixCardPlay(cards []Card) bool {
+// checkSixCardPlay checks if a 6-card play is valid
+func (r *Rule) checkSixCardPlay(cards []Card) error {
 	// Check for six of a kind
 	if r.allSameRank(cards) {
-		return true
+		return nil
 	}
 
 	rankCount := r.countRanks(cards)
@@ -373,23 +298,32 @@ func (r *Rule) isValidSixCardPlay(cards []Card) bool {
 	if len(rankCount) == 3 {
 		for _, count := range rankCount {
 			if count != 2 {
-				return false
+				return illegalCombination("three pairs need two cards of each rank")
 			}
 		}
-		return r.areRanksConsecutive(getSortedRanks(rankCount))
+		if !r.isTube(cards) {
+			return illegalCombination("pair ranks must be consecutive")
+		}
+		return nil
 	}
 
 	// Check for two triplets with consecutive ranks
 	if len(rankCount) == 2 {
 		for _, count := range rankCount {
 			if count != 3 {
-				return false
+				return illegalCombination("two triples need three cards of each rank")
 			}
 		}
-		return r.areRanksConsecutive(getSortedRanks(rankCount))
+		if !r.isPlate(cards) {
+			return illegalCombination("triple ranks must be consecutive")
+		}
+		return nil
 	}
 
-	return false
+	if err := r.checkRunLength(cards); err != nil {
+		return err
+	}
+	return illegalCombination("6 cards must be three consecutive pairs, two consecutive triples or all the same rank")
 }
 
 // allSameRank checks if all cards have the same rank
@@ -415,31 +349,9 @@ func (r *Rule) countRanks(cards []Card) map[Rank]int {
 	return rankCount
 }
 
-// isStraight checks if cards form a straight (consecutive ranks)
+// isStraight checks if cards form a straight: 5 cards of consecutive ranks
 func (r *Rule) isStraight(cards []Card) bool {
-	if len(cards) < 2 {
-		return false
-	}
-
-	sorted := make([]Card, len(cards))
-	copy(sorted, cards)
-	sort.Slice(sorted, func(i, j int) bool {
-		return sorted[i].Rank < sorted[j].Rank
-	})
-
-	// Special case for Ace-5 straight (A-2-3-4-5)
-	if len(sorted) == 5 && sorted[0].Rank == Two && sorted[1].Rank == Three &&
-		sorted[2].Rank == Four && sorted[3].Rank == Five && sorted[4].Rank == Ace {
-		return true
-	}
-
-	// Check normal straight
-	for i := 1; i < len(sorted); i++ {
-		if int(sorted[i].Rank)-int(sorted[i-1].Rank) != 1 {
-			return false
-		}
-	}
-	return true
+	return len(cards) == 5 && r.isRun(cards, 5, 1)
 }
 
 // isStraightFlush checks if the cards form a straight flush (5 consecutive ranks of the same suit)
@@ -460,24 +372,73 @@ func (r *Rule) isStraightFlush(cards []Card) bool {
 	return r.isStraight(cards)
 }
 
-// areRanksConsecutive checks if the ranks are consecutive
-func (r *Rule) areRanksConsecutive(ranks []Rank) bool {
-	if len(ranks) < 2 {
-		return true
+// isTube checks if the cards form a tube: three pairs of consecutive ranks
+func (r *Rule) isTube(cards []Card) bool {
+	return len(cards) == 6 && r.isRun(cards, 3, 2)
+}
+
+// isPlate checks if the cards form a plate: two triples of consecutive ranks
+func (r *Rule) isPlate(cards []Card) bool {
+	return len(cards) == 6 && r.isRun(cards, 2, 3)
+}
+
+// isRun checks if the cards hold exactly width cards of each of length consecutive ranks
+func (r *Rule) isRun(cards []Card, length int, width int) bool {
+	if length < 2 {
+		return false
 	}
+	rankCount := r.countRanks(cards)
+	if len(rankCount) != length {
+		return false
+	}
+	for _, count := range rankCount {
+		if count != width {
+			return false
+		}
+	}
+	_, ok := r.runTop(getSortedRanks(rankCount))
+	return ok
+}
 
-	sort.Slice(ranks, func(i, j int) bool {
-		return ranks[i] < ranks[j]
-	})
+// runTop returns the highest rank of a run and whether the sorted distinct ranks are consecutive.
+// An ace follows a king or, when aces are low, comes before a two, so A-2-3-4-5 runs up to 5.
+// A run never wraps around from an ace to a two, and jokers never belong to a run.
+func (r *Rule) runTop(ranks []Rank) (Rank, bool) {
+	if len(ranks) == 0 || ranks[len(ranks)-1] > Ace {
+		return 0, false
+	}
+	if areConsecutive(ranks) {
+		return ranks[len(ranks)-1], true
+	}
+	if r.Profile().AceLow && ranks[0] == Two && ranks[len(ranks)-1] == Ace && areConsecutive(ranks[:len(ranks)-1]) {
+		return ranks[len(ranks)-2], true
+	}
+	return 0, false
+}
 
-	// Special case for Ace-2-3 straight
-	if len(ranks) == 6 && ranks[0] == Two && ranks[1] == Three && ranks[2] == Four &&
-		ranks[3] == Five && ranks[4] == Six && ranks[5] == Ace {
-		return true
+// runTopOf returns the highest rank of the run formed by the cards
+func (r *Rule) runTopOf(cards []Card) Rank {
+	top, _ := r.runTop(getSortedRanks(r.countRanks(cards)))
+	return top
+}
+
+// checkRunLength returns an error if the cards would form a straight, a tube or a plate of another length, nil otherwise
+func (r *Rule) checkRunLength(cards []Card) error {
+	switch {
+	case r.isRun(cards, len(cards), 1):
+		return illegalCombination("straight needs exactly 5 cards")
+	case len(cards)%2 == 0 && r.isRun(cards, len(cards)/2, 2):
+		return illegalCombination("consecutive pairs need exactly 3 pairs")
+	case len(cards)%3 == 0 && r.isRun(cards, len(cards)/3, 3):
+		return illegalCombination("consecutive triples need exactly 2 triples")
 	}
+	return nil
+}
 
+// areConsecutive checks if the sorted ranks go up one at a time
+func areConsecutive(ranks []Rank) bool {
 	for i := 1; i < len(ranks); i++ {
-		if int(ranks[i])-int(ranks[i-1]) != 1 {
+		if ranks[i]-ranks[i-1] != 1 {
 			return false
 		}
 	}
@@ -3,4 +3,15 @@ package models
 type RuleAPI interface {
 	IsPlayValid(play []Card) bool
 	IsCounterPlayValid(play []Card, counterPlay []Card) bool
+	IsEquivalentValid(attempt []Card, equivalent []Card) bool
+	CheckPlay(play []Card) error
+	CheckCounterPlay(play []Card, counterPlay []Card) error
+	Classify(play []Card) (Combination, error)
+	CompareRank(t CombinationType, rank1 Rank, rank2 Rank) int
+	Beats(play Combination, counter Combination) bool
+	Readings(play []Card) []Reading
+	SortCards(cards []Card, strategy SortStrategy)
 }
+
+// Verify at compile time that *Rule implements RuleAPI
+var _ RuleAPI = (*Rule)(nil)
@@ -0,0 +1,173 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SortStrategy is a way to order the cards of a hand, heart wild cards always come first
+type SortStrategy string
+
+// Constants for sort strategies
+const (
+	SortByStrength    SortStrategy = "strength"    // the strongest single cards first: jokers, the trump rank, then A down to 2
+	SortBySuit        SortStrategy = "suit"        // jokers, then spades, hearts, clubs and diamonds, each by strength
+	SortByCombination SortStrategy = "combination" // bombs, then straight flushes, then the other cards by strength
+)
+
+// suitOrder orders the suits of cards of the same rank
+var suitOrder = map[Suit]int{Spade: 4, Heart: 3, Club: 2, Diamond: 1}
+
+// ParseSortStrategy parses the name of a sort strategy
+func ParseSortStrategy(s string) (SortStrategy, error) {
+	switch strategy := SortStrategy(strings.ToLower(s)); strategy {
+	case SortByStrength, SortBySuit, SortByCombination:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("invalid sort strategy: %s, use strength, suit or combination", s)
+	}
+}
+
+// SortCards sorts cards in place with the given strategy, heart wild cards first
+func (r *Rule) SortCards(cards []Card, strategy SortStrategy) {
+	switch strategy {
+	case SortBySuit:
+		sort.SliceStable(cards, func(i, j int) bool {
+			a, b := cards[i], cards[j]
+			if wa, wb := r.IsWildCard(a), r.IsWildCard(b); wa != wb {
+				return wa
+			}
+			if ja, jb := a.Rank >= Joker, b.Rank >= Joker; ja != jb {
+				return ja
+			}
+			if a.Suit != b.Suit {
+				return suitOrder[a.Suit] > suitOrder[b.Suit]
+			}
+			return r.isStrongerCard(a, b)
+		})
+	case SortByCombination:
+		r.sortByCombination(cards)
+	default:
+		sort.SliceStable(cards, func(i, j int) bool {
+			a, b := cards[i], cards[j]
+			if wa, wb := r.IsWildCard(a), r.IsWildCard(b); wa != wb {
+				return wa
+			}
+			return r.isStrongerCard(a, b)
+		})
+	}
+}
+
+// isStrongerCard orders two cards by their strength as single cards, then by suit and deck
+func (r *Rule) isStrongerCard(a Card, b Card) bool {
+	if a.Rank != b.Rank {
+		return r.IsRankGreater(a.Rank, b.Rank)
+	}
+	if a.Suit != b.Suit {
+		return suitOrder[a.Suit] > suitOrder[b.Suit]
+	}
+	return a.Deck < b.Deck
+}
+
+// sortByCombination sorts cards with the wild cards first, then the bombs from the strongest, then the straight flushes
+// that can be made from the cards left from the highest, then the other cards by strength
+func (r *Rule) sortByCombination(cards []Card) {
+	var wilds, rest []Card
+	for _, card := range cards {
+		if r.IsWildCard(card) {
+			wilds = append(wilds, card)
+		} else {
+			rest = append(rest, card)
+		}
+	}
+	r.SortCards(rest, SortByStrength)
+
+	// bombs of four or more cards of the same rank
+	byRank := make(map[Rank][]Card)
+	for _, card := range rest {
+		byRank[card.Rank] = append(byRank[card.Rank], card)
+	}
+	var bombs [][]Card
+	for _, group := range byRank {
+		if len(group) >= 4 && group[0].Rank < Joker {
+			bombs = append(bombs, group)
+		}
+	}
+	sort.Slice(bombs, func(i, j int) bool {
+		if len(bombs[i]) != len(bombs[j]) {
+			return len(bombs[i]) > len(bombs[j])
+		}
+		return r.IsRankGreater(bombs[i][0].Rank, bombs[j][0].Rank)
+	})
+	sorted := append([]Card{}, wilds...)
+	used := make(map[int]bool)
+	for _, bomb := range bombs {
+		sorted = append(sorted, bomb...)
+		for i, card := range rest {
+			if card.Rank == bomb[0].Rank {
+				used[i] = true
+			}
+		}
+	}
+
+	// straight flushes of the cards left, the highest first
+	for {
+		flush := r.highestStraightFlush(rest, used)
+		if flush == nil {
+			break
+		}
+		for _, i := range flush {
+			sorted = append(sorted, rest[i])
+			used[i] = true
+		}
+	}
+
+	for i, card := range rest {
+		if !used[i] {
+			sorted = append(sorted, card)
+		}
+	}
+	copy(cards, sorted)
+}
+
+// highestStraightFlush returns the indexes in cards of the straight flush with the highest top rank
+// that can be made of the cards not used, from its top card down, or nil if there is none
+func (r *Rule) highestStraightFlush(cards []Card, used map[int]bool) []int {
+	var best []int
+	var bestTop Rank
+	for _, suit := range []Suit{Spade, Heart, Club, Diamond} {
+		index := make(map[Rank]int)
+		for i, card := range cards {
+			if !used[i] && card.Suit == suit && card.Rank <= Ace {
+				if _, ok := index[card.Rank]; !ok {
+					index[card.Rank] = i
+				}
+			}
+		}
+		for top := Ace; top >= Five; top-- {
+			if top <= bestTop {
+				break
+			}
+			var run []int
+			for rank := top; rank > top-5; rank-- {
+				card := rank
+				if rank < Two {
+					// the ace below the two, when aces are low
+					if !r.Profile().AceLow {
+						break
+					}
+					card = Ace
+				}
+				if i, ok := index[card]; ok {
+					run = append(run, i)
+				}
+			}
+			if len(run) == 5 {
+				best, bestTop = run, top
+				break
+			}
+		}
+	}
+	return best
+}
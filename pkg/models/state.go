@@ -0,0 +1,120 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// TableState is a snapshot of the table as seen by one player, sent with the "state" server message
+type TableState struct {
+	// Phase is the current phase of the game
+	Phase Phase `json:"phase"`
+	// Seats lists every seat at the table, occupied or not
+	Seats []SeatState `json:"seats"`
+	// Teams lists the teams with their levels
+	Teams []TeamState `json:"teams"`
+	// TrumpRank is the trump rank of the current round
+	TrumpRank string `json:"trumpRank"`
+	// CurrentIndex is the index of the player whose turn it is
+	CurrentIndex int `json:"currentIndex"`
+	// LastPlayedCards is the play on top of the table, empty if the next player leads
+	LastPlayedCards string `json:"lastPlayedCards"`
+	// LastPlayedIndex is the index of the player who made the top play
+	LastPlayedIndex int `json:"lastPlayedIndex"`
+	// PassedIndexes lists the players who passed since the top play
+	PassedIndexes []int `json:"passedIndexes"`
+	// FinishedIndexes lists the players who went out this round, in finishing order
+	FinishedIndexes []int `json:"finishedIndexes"`
+	// Index is the index of the player the snapshot was built for
+	Index int `json:"index"`
+	// Hand is the requesting player's own hand
+	Hand string `json:"hand"`
+}
+
+// SeatState describes one seat at the table
+type SeatState struct {
+	Index     int    `json:"index"`
+	Name      string `json:"name"`
+	Occupied  bool   `json:"occupied"`
+	Team      int    `json:"team"`
+	CardsLeft int    `json:"cardsLeft"`
+	Away      bool   `json:"away"`
+	// Stats counts what the player in the seat did at the table
+	Stats PlayerStats `json:"stats"`
+}
+
+// TeamState describes one team and its level
+type TeamState struct {
+	Name    string `json:"name"`
+	Level   string `json:"level"`
+	Members []int  `json:"members"`
+	// AAttempts is the number of rounds the team played at level A without winning the match
+	AAttempts int `json:"aAttempts"`
+}
+
+// TeamOf returns the default team of the player at index, partners sit across from each other
+func TeamOf(index int) int {
+	return index % 2
+}
+
+// NewTableState builds the table snapshot for the player at index from the game info and the players seated
+func NewTableState(info InfoAPI, players map[int]*Player, index int) *TableState {
+	snapshot := info.Snapshot()
+	state := &TableState{
+		Phase:           snapshot.Phase,
+		TrumpRank:       RankToString(snapshot.TrumpRank),
+		CurrentIndex:    snapshot.CurrentPlayerIndex,
+		LastPlayedCards: CardsString(snapshot.LastPlayedCards),
+		LastPlayedIndex: snapshot.LastPlayedIndex,
+		PassedIndexes:   snapshot.PassedIndexes,
+		FinishedIndexes: snapshot.FinishedIndexes,
+		Index:           index,
+	}
+	if player, ok := players[index]; ok {
+		state.Hand = CardsString(player.GetHand().GetCards())
+	}
+
+	for i := 0; i < snapshot.NumPlayers; i++ {
+		name, occupied := snapshot.Names[i]
+		seat := SeatState{
+			Index:    i,
+			Name:     name,
+			Occupied: occupied,
+			Team:     info.GetTeamOf(i),
+			Away:     snapshot.Away[i],
+		}
+		if player, ok := players[i]; ok {
+			seat.CardsLeft = player.GetHand().Count()
+			seat.Stats = player.GetStats()
+		}
+		state.Seats = append(state.Seats, seat)
+	}
+
+	for _, team := range snapshot.Teams {
+		state.Teams = append(state.Teams, TeamState{
+			Name:      team.Name,
+			Level:     RankToString(team.Level),
+			Members:   team.Members,
+			AAttempts: team.AAttempts,
+		})
+	}
+	return state
+}
+
+// ConstructStateServerMessage constructs the data of a state server message from the given snapshot
+func ConstructStateServerMessage(state *TableState) string {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// ParseStateServerMessage parses the data of a state server message
+func ParseStateServerMessage(msg string) (*TableState, error) {
+	var state TableState
+	if err := json.Unmarshal([]byte(msg), &state); err != nil {
+		return nil, fmt.Errorf("failed to parse state message: %w", err)
+	}
+	return &state, nil
+}
@@ -0,0 +1,187 @@
+package models
+
+import "fmt"
+
+// TableConfig describes a supported table size: the seats, the cards dealt,
+// how the seats form teams and how many levels the winning team goes up
+type TableConfig struct {
+	// Name identifies the configuration
+	Name string `json:"name"`
+	// NumPlayers is the number of seats at the table
+	NumPlayers int `json:"numPlayers"`
+	// NumDecks is the number of 54 card decks shuffled together
+	NumDecks int `json:"numDecks"`
+	// HandSize is the number of cards dealt to each player, 0 deals every card of the decks
+	HandSize int `json:"handSize"`
+	// Teams lists the seats of each team
+	Teams [][]int `json:"teams"`
+	// LevelUps is the number of levels the winning team goes up,
+	// by the place its last player finished in, starting at place 1
+	LevelUps []int `json:"levelUps"`
+	// WinAtA is the fewest levels a team playing at level A must go up to win the match, 0 for 2
+	WinAtA int `json:"winAtA,omitempty"`
+}
+
+// Names of the supported table configurations
+const (
+	TablePractice   = "practice" // two players, one against the other with a reduced deck
+	TableFour       = "four"     // four players in two teams of two
+	TableSixThreeBy = "six-3x2"  // six players in three teams of two
+	TableSixTwoBy   = "six-2x3"  // six players in two teams of three
+)
+
+// NewTableConfig returns the supported configuration for numPlayers players in numTeams teams,
+// numTeams 0 picks the usual number of teams for the table size
+func NewTableConfig(numPlayers int, numTeams int) (TableConfig, error) {
+	var config TableConfig
+	switch {
+	case numPlayers == 2 && (numTeams == 0 || numTeams == 2):
+		config = TableConfig{
+			Name:     TablePractice,
+			HandSize: 13,
+			Teams:    [][]int{{0}, {1}},
+			LevelUps: []int{1, 1},
+			WinAtA:   1,
+		}
+	case numPlayers == 4 && (numTeams == 0 || numTeams == 2):
+		config = TableConfig{
+			Name:     TableFour,
+			Teams:    [][]int{{0, 2}, {1, 3}},
+			LevelUps: []int{0, 3, 2, 1},
+		}
+	case numPlayers == 6 && (numTeams == 0 || numTeams == 3):
+		config = TableConfig{
+			Name:     TableSixThreeBy,
+			Teams:    [][]int{{0, 3}, {1, 4}, {2, 5}},
+			LevelUps: []int{0, 3, 2, 1, 1, 1},
+		}
+	case numPlayers == 6 && numTeams == 2:
+		config = TableConfig{
+			Name:     TableSixTwoBy,
+			Teams:    [][]int{{0, 2, 4}, {1, 3, 5}},
+			LevelUps: []int{0, 0, 3, 2, 1, 1},
+		}
+	default:
+		return config, fmt.Errorf("unsupported table: %d players in %d teams, play with 2, 4 or 6 players", numPlayers, numTeams)
+	}
+	config.NumPlayers = numPlayers
+	config.NumDecks = NumOfDecks(numPlayers)
+	return config, config.Validate()
+}
+
+// Validate returns an error if the configuration cannot be played:
+// every seat must belong to exactly one team, teams must be of the same size with partners not sitting
+// next to each other, the decks must deal every player the same number of cards and the winning team
+// must go up at least one level wherever its last player finishes, and enough levels to win the match at A
+// for some finishing place
+func (c TableConfig) Validate() error {
+	if c.NumPlayers < 2 {
+		return fmt.Errorf("table %s: at least 2 players are needed, got %d", c.Name, c.NumPlayers)
+	}
+	if c.NumDecks < 1 {
+		return fmt.Errorf("table %s: at least 1 deck is needed, got %d", c.Name, c.NumDecks)
+	}
+	numCards := c.NumDecks * 54
+	if c.HandSize == 0 && numCards%c.NumPlayers != 0 {
+		return fmt.Errorf("table %s: %d cards cannot be dealt evenly to %d players", c.Name, numCards, c.NumPlayers)
+	}
+	if c.HandSize < 0 || c.HandSize*c.NumPlayers > numCards {
+		return fmt.Errorf("table %s: cannot deal %d cards to %d players from %d decks", c.Name, c.HandSize, c.NumPlayers, c.NumDecks)
+	}
+
+	if len(c.Teams) < 2 {
+		return fmt.Errorf("table %s: at least 2 teams are needed, got %d", c.Name, len(c.Teams))
+	}
+	seen := make(map[int]bool)
+	for t, members := range c.Teams {
+		if len(members) != len(c.Teams[0]) {
+			return fmt.Errorf("table %s: team %d has %d players, team 0 has %d", c.Name, t, len(members), len(c.Teams[0]))
+		}
+		for _, seat := range members {
+			if seat < 0 || seat >= c.NumPlayers || seen[seat] {
+				return fmt.Errorf("table %s: seat %d of team %d is out of range or in two teams", c.Name, seat, t)
+			}
+			seen[seat] = true
+		}
+		for _, seat := range members {
+			next := (seat + 1) % c.NumPlayers
+			for _, partner := range members {
+				if len(members) > 1 && partner == next {
+					return fmt.Errorf("table %s: partners %d and %d of team %d sit next to each other", c.Name, seat, partner, t)
+				}
+			}
+		}
+	}
+	if len(seen) != c.NumPlayers {
+		return fmt.Errorf("table %s: %d of %d seats belong to a team", c.Name, len(seen), c.NumPlayers)
+	}
+
+	if len(c.LevelUps) != c.NumPlayers {
+		return fmt.Errorf("table %s: %d level ups for %d places", c.Name, len(c.LevelUps), c.NumPlayers)
+	}
+	for place := len(c.Teams[0]); place <= c.NumPlayers; place++ {
+		if c.LevelUps[place-1] < 1 {
+			return fmt.Errorf("table %s: a team whose last player finishes #%d must go up at least 1 level", c.Name, place)
+		}
+	}
+	if c.WinAtA < 0 {
+		return fmt.Errorf("table %s: invalid levels to win at A: %d", c.Name, c.WinAtA)
+	}
+	reachable := false
+	for _, up := range c.LevelUps {
+		reachable = reachable || up >= c.LevelsToWinAtA()
+	}
+	if !reachable {
+		return fmt.Errorf("table %s: a team at level A must go up %d levels to win, the table goes up at most %v", c.Name, c.LevelsToWinAtA(), c.LevelUps)
+	}
+	return nil
+}
+
+// LevelsToWinAtA returns the fewest levels a team playing at level A must go up to win the match
+func (c TableConfig) LevelsToWinAtA() int {
+	if c.WinAtA > 0 {
+		return c.WinAtA
+	}
+	return 2
+}
+
+// DealtHandSize returns the number of cards dealt to each player, HandSize or a share of every card of the decks
+func (c TableConfig) DealtHandSize() int {
+	if c.HandSize > 0 {
+		return c.HandSize
+	}
+	return c.NumDecks * 54 / c.NumPlayers
+}
+
+// NewTeams creates the teams of the table at level Two
+func (c TableConfig) NewTeams() []Team {
+	teams := make([]Team, len(c.Teams))
+	for t, members := range c.Teams {
+		teams[t] = Team{
+			Name:    fmt.Sprintf("Group%d", t+1),
+			Members: append([]int{}, members...),
+			Level:   Two,
+		}
+	}
+	return teams
+}
+
+// LevelsUp returns the number of levels the winning team goes up when its last player finished in place,
+// starting at place 1
+func (c TableConfig) LevelsUp(place int) int {
+	if place < 1 || place > len(c.LevelUps) || c.LevelUps[place-1] < 1 {
+		return 1
+	}
+	return c.LevelUps[place-1]
+}
+
+// copyTable returns a deep copy of c
+func copyTable(c TableConfig) TableConfig {
+	teams := make([][]int, len(c.Teams))
+	for t, members := range c.Teams {
+		teams[t] = append([]int{}, members...)
+	}
+	c.Teams = teams
+	c.LevelUps = append([]int{}, c.LevelUps...)
+	return c
+}
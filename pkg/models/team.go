@@ -0,0 +1,60 @@
+package models
+
+import "fmt"
+
+// MaxAAttempts is the default number of rounds a team can play at level A before it falls back to level Two
+const MaxAAttempts = 3
+
+// Team is a group of partners playing for the same level
+type Team struct {
+	// Name is the display name of the team
+	Name string `json:"name"`
+	// Members lists the indexes of the seats belonging to the team
+	Members []int `json:"members"`
+	// Level is the level the team is at, the trump rank when the team wins a round
+	Level Rank `json:"level"`
+	// AAttempts is the number of rounds the team played at level A without winning the match
+	AAttempts int `json:"aAttempts"`
+}
+
+// NewTeams creates the teams of a table with numPlayers seats,
+// partners sit across from each other so seats 0 and 2 play against seats 1 and 3
+func NewTeams(numPlayers int) []Team {
+	teams := []Team{
+		{Name: "Group1", Members: []int{}, Level: Two},
+		{Name: "Group2", Members: []int{}, Level: Two},
+	}
+	for index := 0; index < numPlayers; index++ {
+		t := TeamOf(index)
+		teams[t].Members = append(teams[t].Members, index)
+	}
+	return teams
+}
+
+// HasMember returns true if the seat at index belongs to the team
+func (t Team) HasMember(index int) bool {
+	for _, member := range t.Members {
+		if member == index {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the team name with its level
+func (t Team) String() string {
+	return fmt.Sprintf("%s (level %s)", t.Name, RankToString(t.Level))
+}
+
+// copyTeams returns a deep copy of teams
+func copyTeams(teams []Team) []Team {
+	if teams == nil {
+		return nil
+	}
+	c := make([]Team, len(teams))
+	for i, team := range teams {
+		c[i] = team
+		c[i].Members = append([]int{}, team.Members...)
+	}
+	return c
+}